@@ -0,0 +1,171 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+// Flatten creates a filter that composes an image over the background color,
+// removing the transparency of an image with opaque background.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Flatten(color.White),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Flatten(background color.Color) Filter {
+	bg := pixelclr(background)
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			c1 := px.A
+			c0 := (1 - c1) * bg.A
+			cs := c0 + c1
+			if cs <= 0 {
+				return pixel{0, 0, 0, 0}
+			}
+			c0 /= cs
+			c1 /= cs
+			return pixel{
+				bg.R*c0 + px.R*c1,
+				bg.G*c0 + px.G*c1,
+				bg.B*c0 + px.B*c1,
+				cs,
+			}
+		},
+	}
+}
+
+// SetAlpha creates a filter that sets the alpha channel of an image to the given value in range [0, 1].
+func SetAlpha(alpha float32) Filter {
+	return &channelsFilter{
+		fns: [4]func(float32) float32{nil, nil, nil, func(float32) float32 {
+			return alpha
+		}},
+	}
+}
+
+type setAlphaMaskFilter struct {
+	mask image.Image
+}
+
+func (p *setAlphaMaskFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *setAlphaMaskFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	maskb := p.mask.Bounds()
+	pixGetter := newPixelGetter(src)
+	maskGetter := newPixelGetter(p.mask)
+	pixSetter := newPixelSetter(dst)
+
+	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := srcb.Min.X; x < srcb.Max.X; x++ {
+				px := pixGetter.getPixel(x, y)
+				mx, my := maskb.Min.X+x-srcb.Min.X, maskb.Min.Y+y-srcb.Min.Y
+				if image.Pt(mx, my).In(maskb) {
+					m := maskGetter.getPixel(mx, my)
+					px.A = (0.299*m.R + 0.587*m.G + 0.114*m.B) * m.A
+				}
+				pixSetter.setPixel(dstb.Min.X+x-srcb.Min.X, dstb.Min.Y+y-srcb.Min.Y, px)
+			}
+		}
+	})
+}
+
+// SetAlphaMask creates a filter that replaces the alpha channel of an image with the luminance of the mask image
+// (white is opaque, black is transparent). The mask is aligned with the image by their Min points,
+// the pixels outside of the mask keep their alpha.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.SetAlphaMask(mask),
+//	)
+//	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func SetAlphaMask(mask image.Image) Filter {
+	return &setAlphaMaskFilter{
+		mask: mask,
+	}
+}
+
+// AlphaFromLuminance creates a filter that multiplies the alpha channel of an image by the luminance of its colors,
+// so that the white pixels keep their opacity and the black pixels become transparent.
+// Applied to an opaque grayscale image, it produces an alpha mask.
+func AlphaFromLuminance() Filter {
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			px.A *= 0.299*px.R + 0.587*px.G + 0.114*px.B
+			return px
+		},
+	}
+}
+
+// InvertAlpha creates a filter that negates the alpha channel of an image.
+func InvertAlpha() Filter {
+	return &channelsFilter{
+		fns: [4]func(float32) float32{nil, nil, nil, func(x float32) float32 {
+			return 1 - x
+		}},
+	}
+}
+
+// PremultiplyAlpha multiplies the color channels of the image by its alpha channel in place.
+// The filters treat the giftimage.F32RGBA images as not premultiplied,
+// this function converts the result for the software that expects the premultiplied colors.
+func PremultiplyAlpha(img *giftimage.F32RGBA) {
+	b := img.Bounds()
+	parallelize(defaultOptions.Parallelization, b.Min.Y, b.Max.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			i := img.PixOffset(b.Min.X, y)
+			for x := b.Min.X; x < b.Max.X; x++ {
+				a := img.Pix[i+3]
+				img.Pix[i+0] *= a
+				img.Pix[i+1] *= a
+				img.Pix[i+2] *= a
+				i += 4
+			}
+		}
+	})
+}
+
+// UnpremultiplyAlpha divides the color channels of the image by its alpha channel in place,
+// it reverses PremultiplyAlpha. The fully transparent pixels become transparent black,
+// the same way the premultiplied images (for example, image.RGBA) are read by the filters.
+func UnpremultiplyAlpha(img *giftimage.F32RGBA) {
+	b := img.Bounds()
+	parallelize(defaultOptions.Parallelization, b.Min.Y, b.Max.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			i := img.PixOffset(b.Min.X, y)
+			for x := b.Min.X; x < b.Max.X; x++ {
+				a := img.Pix[i+3]
+				if a == 0 {
+					img.Pix[i+0] = 0
+					img.Pix[i+1] = 0
+					img.Pix[i+2] = 0
+				} else {
+					q := 1 / a
+					img.Pix[i+0] *= q
+					img.Pix[i+1] *= q
+					img.Pix[i+2] *= q
+				}
+				i += 4
+			}
+		}
+	})
+}
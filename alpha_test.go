@@ -0,0 +1,146 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+func TestAlphaFilters(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(-1, -1, 2, 0))
+	src.Pix = []uint8{
+		0x80, 0x40, 0x20, 0xff,
+		0xff, 0x00, 0x00, 0x80,
+		0x10, 0x20, 0x30, 0x00,
+	}
+
+	mask := image.NewGray(image.Rect(5, 5, 7, 6))
+	mask.Pix = []uint8{0x40, 0xff}
+
+	testData := []struct {
+		desc   string
+		filter Filter
+		dstPix []uint8
+	}{
+		{
+			"flatten white",
+			Flatten(color.White),
+			[]uint8{
+				0x80, 0x40, 0x20, 0xff,
+				0xff, 0x7f, 0x7f, 0xff,
+				0xff, 0xff, 0xff, 0xff,
+			},
+		},
+		{
+			"flatten semi-transparent",
+			Flatten(color.NRGBA{0x00, 0x00, 0xff, 0x80}),
+			[]uint8{
+				0x80, 0x40, 0x20, 0xff,
+				0xaa, 0x00, 0x55, 0xc0,
+				0x00, 0x00, 0xff, 0x80,
+			},
+		},
+		{
+			"flatten transparent",
+			Flatten(color.Transparent),
+			[]uint8{
+				0x80, 0x40, 0x20, 0xff,
+				0xff, 0x00, 0x00, 0x80,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			"set alpha",
+			SetAlpha(0.5),
+			[]uint8{
+				0x80, 0x40, 0x20, 0x80,
+				0xff, 0x00, 0x00, 0x80,
+				0x10, 0x20, 0x30, 0x80,
+			},
+		},
+		{
+			"set alpha mask",
+			SetAlphaMask(mask),
+			[]uint8{
+				0x80, 0x40, 0x20, 0x40,
+				0xff, 0x00, 0x00, 0xff,
+				0x10, 0x20, 0x30, 0x00,
+			},
+		},
+		{
+			"alpha from luminance",
+			AlphaFromLuminance(),
+			[]uint8{
+				0x80, 0x40, 0x20, 0x4f,
+				0xff, 0x00, 0x00, 0x26,
+				0x10, 0x20, 0x30, 0x00,
+			},
+		},
+		{
+			"invert alpha",
+			InvertAlpha(),
+			[]uint8{
+				0x80, 0x40, 0x20, 0x00,
+				0xff, 0x00, 0x00, 0x7f,
+				0x10, 0x20, 0x30, 0xff,
+			},
+		},
+	}
+
+	for _, d := range testData {
+		dst := image.NewNRGBA(d.filter.Bounds(src.Bounds()))
+		d.filter.Draw(dst, src, nil)
+		if !checkBoundsAndPix(dst.Bounds(), image.Rect(0, 0, 3, 1), dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v", d.desc, dst.Pix)
+		}
+	}
+}
+
+func TestPremultiplyAlpha(t *testing.T) {
+	img := giftimage.NewF32RGBA(image.Rect(1, 1, 4, 2))
+	copy(img.Pix, []float32{
+		0.8, 0.4, 0.2, 1,
+		1, 0.5, 0.25, 0.5,
+		0.3, 0.2, 0.1, 0,
+	})
+
+	PremultiplyAlpha(img)
+	want := []float32{
+		0.8, 0.4, 0.2, 1,
+		0.5, 0.25, 0.125, 0.5,
+		0, 0, 0, 0,
+	}
+	for i := range want {
+		if img.Pix[i] != want[i] {
+			t.Fatalf("PremultiplyAlpha: expected %v got %v", want, img.Pix)
+		}
+	}
+
+	// the premultiplied data gives the same colors as an image.RGBA
+	rgba := image.NewRGBA(img.Bounds())
+	for i, v := range img.Pix {
+		rgba.Pix[i] = uint8(v*255 + 0.5)
+	}
+
+	UnpremultiplyAlpha(img)
+	want = []float32{
+		0.8, 0.4, 0.2, 1,
+		1, 0.5, 0.25, 0.5,
+		0, 0, 0, 0,
+	}
+	for i := range want {
+		if img.Pix[i] != want[i] {
+			t.Fatalf("UnpremultiplyAlpha: expected %v got %v", want, img.Pix)
+		}
+	}
+
+	g1 := newPixelGetter(rgba)
+	g2 := newPixelGetter(img)
+	for x := 1; x < 4; x++ {
+		if px1, px2 := g1.getPixel(x, 1), g2.getPixel(x, 1); !comparePixels(px1, px2, 0.01) {
+			t.Errorf("pixel %d: RGBA %v, F32RGBA %v", x, px1, px2)
+		}
+	}
+}
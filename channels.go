@@ -0,0 +1,144 @@
+package gift
+
+import (
+	"image"
+	"strings"
+)
+
+// Channel is a color channel of an image.
+type Channel int
+
+// Color channels.
+const (
+	RedChannel Channel = iota
+	GreenChannel
+	BlueChannel
+	AlphaChannel
+)
+
+// pixelChannels returns the channels of the pixel as an array indexed by Channel.
+func pixelChannels(px pixel) [4]float32 {
+	return [4]float32{px.R, px.G, px.B, px.A}
+}
+
+// ExtractChannel creates a filter that produces a grayscale image from the specified channel of an image.
+// The resulting image is opaque, the alpha channel is extracted as is (the colors are not premultiplied).
+// To preserve the 16-bit precision, use a 16-bit destination image.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ExtractChannel(gift.AlphaChannel),
+//	)
+//	mask := image.NewGray16(g.Bounds(src.Bounds()))
+//	g.Draw(mask, src)
+//
+func ExtractChannel(channel Channel) Filter {
+	if channel < RedChannel || channel > AlphaChannel {
+		channel = RedChannel
+	}
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			v := pixelChannels(px)[channel]
+			return pixel{v, v, v, 1}
+		},
+	}
+}
+
+// Swizzle creates a filter that rearranges the channels of an image.
+// The pattern specifies the sources of the red, green, blue and alpha channels of the result:
+// the letters 'r', 'g', 'b' and 'a' select a channel of the source image,
+// '0' and '1' set the channel to the minimum or the maximum value.
+// The channels missing from a short pattern and the unknown characters keep their values.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Swizzle("bgra"), // swap the red and blue channels
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Swizzle(pattern string) Filter {
+	srcs := [4]int{0, 1, 2, 3}
+	for i, c := range strings.ToLower(pattern) {
+		if i >= 4 {
+			break
+		}
+		if j := strings.IndexRune("rgba01", c); j >= 0 {
+			srcs[i] = j
+		}
+	}
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			vals := [6]float32{px.R, px.G, px.B, px.A, 0, 1}
+			return pixel{vals[srcs[0]], vals[srcs[1]], vals[srcs[2]], vals[srcs[3]]}
+		},
+	}
+}
+
+// MergeChannels creates an image from the separate images of the red, green, blue and alpha channels.
+// The channel images are treated as grayscale images (the luminance of a color image is used).
+// A nil red, green or blue image produces a zero channel, a nil alpha image produces an opaque image.
+// The resulting image is the size of the area common to all the given images, starting at (0, 0);
+// the channel images are read starting from their Min points. It has 16 bits per channel.
+//
+// Example:
+//
+//	// replace the alpha channel of an image with a mask
+//	r := gift.New(gift.ExtractChannel(gift.RedChannel)).Apply(src)
+//	g := gift.New(gift.ExtractChannel(gift.GreenChannel)).Apply(src)
+//	b := gift.New(gift.ExtractChannel(gift.BlueChannel)).Apply(src)
+//	dst := gift.MergeChannels(r, g, b, mask)
+//
+func MergeChannels(r, g, b, a image.Image) *image.NRGBA64 {
+	imgs := [4]image.Image{r, g, b, a}
+	var size image.Point
+	first := true
+	for _, img := range imgs {
+		if img == nil {
+			continue
+		}
+		s := img.Bounds().Size()
+		if first {
+			size = s
+			first = false
+			continue
+		}
+		size.X = minint(size.X, s.X)
+		size.Y = minint(size.Y, s.Y)
+	}
+	dst := image.NewNRGBA64(image.Rect(0, 0, maxint(size.X, 0), maxint(size.Y, 0)))
+	if dst.Rect.Empty() {
+		return dst
+	}
+
+	var planes [4][]pixel
+	for i, img := range imgs {
+		if img != nil {
+			planes[i] = readPixels(img, size.X, size.Y, &defaultOptions)
+		}
+	}
+
+	pixSetter := newPixelSetter(dst)
+	parallelize(defaultOptions.Parallelization, 0, size.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < size.X; x++ {
+				i := y*size.X + x
+				vals := [4]float32{0, 0, 0, 1}
+				for c, plane := range planes {
+					if plane != nil {
+						px := plane[i]
+						if px.R == px.G && px.G == px.B {
+							vals[c] = px.R
+						} else {
+							vals[c] = 0.299*px.R + 0.587*px.G + 0.114*px.B
+						}
+					}
+				}
+				pixSetter.setPixel(x, y, pixel{vals[0], vals[1], vals[2], vals[3]})
+			}
+		}
+	})
+	return dst
+}
@@ -0,0 +1,117 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+func TestExtractChannel(t *testing.T) {
+	src := image.NewNRGBA64(image.Rect(-1, -1, 1, 0))
+	src.SetNRGBA64(-1, -1, color.NRGBA64{0x1234, 0x5678, 0x9abc, 0xdef0})
+	src.SetNRGBA64(0, -1, color.NRGBA64{0xffff, 0x0000, 0x0101, 0x0000})
+
+	testData := []struct {
+		channel Channel
+		want    []uint16
+	}{
+		{RedChannel, []uint16{0x1234, 0xffff}},
+		{GreenChannel, []uint16{0x5678, 0x0000}},
+		{BlueChannel, []uint16{0x9abc, 0x0101}},
+		{AlphaChannel, []uint16{0xdef0, 0x0000}},
+	}
+
+	for _, d := range testData {
+		g := New(ExtractChannel(d.channel))
+		dst := image.NewGray16(g.Bounds(src.Bounds()))
+		g.Draw(dst, src)
+		for x, want := range d.want {
+			if got := dst.Gray16At(x, 0).Y; got != want {
+				t.Errorf("channel %d pixel %d: expected %#04x got %#04x", d.channel, x, want, got)
+			}
+		}
+	}
+}
+
+func TestSwizzle(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Pix = []uint8{0x10, 0x20, 0x30, 0x40, 0xa0, 0xb0, 0xc0, 0xff}
+
+	testData := []struct {
+		pattern string
+		want    []uint8
+	}{
+		{"rgba", []uint8{0x10, 0x20, 0x30, 0x40, 0xa0, 0xb0, 0xc0, 0xff}},
+		{"bgra", []uint8{0x30, 0x20, 0x10, 0x40, 0xc0, 0xb0, 0xa0, 0xff}},
+		{"BGR", []uint8{0x30, 0x20, 0x10, 0x40, 0xc0, 0xb0, 0xa0, 0xff}},
+		{"aaa1", []uint8{0x40, 0x40, 0x40, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"r0gb", []uint8{0x10, 0x00, 0x20, 0x30, 0xa0, 0x00, 0xb0, 0xc0}},
+		{"?x", []uint8{0x10, 0x20, 0x30, 0x40, 0xa0, 0xb0, 0xc0, 0xff}},
+	}
+
+	for _, d := range testData {
+		f := Swizzle(d.pattern)
+		dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+		f.Draw(dst, src, nil)
+		if !comparePix(dst.Pix, d.want) {
+			t.Errorf("pattern %q: expected %#v got %#v", d.pattern, d.want, dst.Pix)
+		}
+	}
+}
+
+func TestMergeChannels(t *testing.T) {
+	r := image.NewGray16(image.Rect(0, 0, 3, 2))
+	g := image.NewGray16(image.Rect(10, 10, 12, 13))
+	b := giftimage.NewF32RGBA(image.Rect(0, 0, 2, 2))
+	a := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := range r.Pix {
+		r.Pix[i] = uint8(i*37 + 1)
+	}
+	for i := range g.Pix {
+		g.Pix[i] = uint8(i*53 + 7)
+	}
+	for i := range b.Pix {
+		b.Pix[i] = float32(i/4) / 4
+	}
+	for i := range a.Pix {
+		a.Pix[i] = uint8(i * 16) // colored: the luminance is used
+	}
+
+	dst := MergeChannels(r, g, b, a)
+	if !dst.Bounds().Eq(image.Rect(0, 0, 2, 2)) {
+		t.Fatalf("bounds: expected %v got %v", image.Rect(0, 0, 2, 2), dst.Bounds())
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			c := dst.NRGBA64At(x, y)
+			if want := r.Gray16At(x, y).Y; c.R != want {
+				t.Errorf("red (%d, %d): expected %#04x got %#04x", x, y, want, c.R)
+			}
+			if want := g.Gray16At(10+x, 10+y).Y; c.G != want {
+				t.Errorf("green (%d, %d): expected %#04x got %#04x", x, y, want, c.G)
+			}
+			if want := uint16(float32(y*2+x)/4*0xffff + 0.5); c.B != want {
+				t.Errorf("blue (%d, %d): expected %#04x got %#04x", x, y, want, c.B)
+			}
+			p := a.NRGBAAt(x, y)
+			want := uint16((0.299*float32(p.R) + 0.587*float32(p.G) + 0.114*float32(p.B)) * 0x101)
+			if absf32(float32(c.A)-float32(want)) > 1 {
+				t.Errorf("alpha (%d, %d): expected %#04x got %#04x", x, y, want, c.A)
+			}
+		}
+	}
+
+	// nil channels
+	dst = MergeChannels(nil, r, nil, nil)
+	if !dst.Bounds().Eq(r.Bounds()) {
+		t.Fatalf("bounds: expected %v got %v", r.Bounds(), dst.Bounds())
+	}
+	if c := dst.NRGBA64At(2, 1); c != (color.NRGBA64{0, r.Gray16At(2, 1).Y, 0, 0xffff}) {
+		t.Errorf("nil channels: unexpected color %v", c)
+	}
+	if dst = MergeChannels(nil, nil, nil, nil); !dst.Bounds().Empty() {
+		t.Errorf("no channels: expected empty image got %v", dst.Bounds())
+	}
+}
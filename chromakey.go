@@ -0,0 +1,75 @@
+package gift
+
+import (
+	"image/color"
+	"math"
+)
+
+// ChromaKey creates a filter that makes the areas of the key color (for example, a green screen) transparent.
+// The alpha is computed from the distance between the chroma components (Cb, Cr) of the pixel color
+// and the key color, so the shadows and the highlights of the background are keyed as well.
+// The colors closer than tolerance become fully transparent, the colors farther than tolerance + softness
+// keep their opacity and the alpha changes smoothly in between. The distances are in range [0, 1],
+// typical values are tolerance = 0.1 and softness = 0.1.
+// The spill of the key color on the semi-transparent edges is neutralized (despill): the dominant channel
+// of the key color (for example, green for a green screen) is limited to the maximum of the other two channels,
+// in proportion to the transparency of the pixel. The opaque foreground is left unchanged.
+// The result is intended for a destination image with the alpha channel (for example, image.NRGBA)
+// that can be drawn over a new background using the OverOperator.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ChromaKey(color.NRGBA{0x00, 0xb1, 0x40, 0xff}, 0.1, 0.1),
+//	)
+//	fg := image.NewNRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(fg, src)
+//	gift.New().DrawAt(background, fg, image.Pt(0, 0), gift.OverOperator)
+//
+func ChromaKey(keyColor color.Color, tolerance, softness float32) Filter {
+	key := pixelclr(keyColor)
+	_, kcb, kcr := RGBToYCbCr(key.R, key.G, key.B)
+	tolerance = maxf32(tolerance, 0)
+	softness = maxf32(softness, 0)
+
+	// the dominant channel of the key color, used for the despill
+	keyChannel := -1
+	switch {
+	case key.G > key.R && key.G > key.B:
+		keyChannel = 1
+	case key.B > key.R && key.B > key.G:
+		keyChannel = 2
+	case key.R > key.G && key.R > key.B:
+		keyChannel = 0
+	}
+
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			_, cb, cr := RGBToYCbCr(px.R, px.G, px.B)
+			d := float32(math.Hypot(float64(cb-kcb), float64(cr-kcr)))
+			var alpha float32
+			switch {
+			case d <= tolerance:
+				alpha = 0
+			case d >= tolerance+softness:
+				alpha = 1
+			default:
+				alpha = (d - tolerance) / softness
+			}
+
+			// limit the key channel to the other channels on the soft part of the matte
+			if alpha < 1 {
+				switch keyChannel {
+				case 0:
+					px.R -= maxf32(px.R-maxf32(px.G, px.B), 0) * (1 - alpha)
+				case 1:
+					px.G -= maxf32(px.G-maxf32(px.R, px.B), 0) * (1 - alpha)
+				case 2:
+					px.B -= maxf32(px.B-maxf32(px.R, px.G), 0) * (1 - alpha)
+				}
+			}
+			px.A *= alpha
+			return px
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestChromaKey(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 1))
+	src.Pix = []uint8{
+		0x20, 0xd0, 0x30, 0xff, // key color
+		0x10, 0x90, 0x20, 0xff, // background in shadow
+		0xff, 0x00, 0x00, 0xff, // red foreground
+		0xff, 0xff, 0xff, 0xff, // white foreground
+		0x58, 0x9c, 0x24, 0xff, // edge: 3/4 key color, 1/4 red
+		0x20, 0xd0, 0x30, 0x80, // semi-transparent key color
+		0xff, 0xff, 0x00, 0xff, // yellow foreground
+		0x00, 0xff, 0xff, 0xff, // cyan foreground
+	}
+
+	g := New(ChromaKey(color.NRGBA{0x20, 0xd0, 0x30, 0xff}, 0.12, 0.2))
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+
+	for _, x := range []int{0, 1, 5} {
+		if a := dst.NRGBAAt(x, 0).A; a != 0 {
+			t.Errorf("pixel %d: expected transparent got alpha %d", x, a)
+		}
+	}
+	// the opaque foreground isn't despilled
+	for _, x := range []int{2, 3, 6, 7} {
+		if c, want := dst.NRGBAAt(x, 0), src.NRGBAAt(x, 0); !compareColorsNRGBA(c, want, 1) {
+			t.Errorf("pixel %d: expected %v got %v", x, want, c)
+		}
+	}
+	edge := dst.NRGBAAt(4, 0)
+	if edge.A < 0x40 || edge.A > 0xa0 {
+		t.Errorf("edge: expected partial alpha got %v", edge)
+	}
+	// the excess of green over red is removed in proportion to the transparency
+	wantG := 0x9c - float32(0x9c-0x58)*(1-float32(edge.A)/0xff)
+	if edge.R != 0x58 || edge.B != 0x24 || absf32(float32(edge.G)-wantG) > 1 {
+		t.Errorf("edge: expected despilled color {88 %v 36} got %v", wantG, edge)
+	}
+
+	// a gray key color doesn't despill
+	g = New(ChromaKey(color.Gray{0x80}, 0.05, 0))
+	g.Draw(dst, src)
+	if c := dst.NRGBAAt(3, 0); c.A != 0 {
+		t.Errorf("gray key: expected transparent white got %v", c)
+	}
+	if c := dst.NRGBAAt(2, 0); c != (color.NRGBA{0xff, 0x00, 0x00, 0xff}) {
+		t.Errorf("gray key: expected unchanged red got %v", c)
+	}
+}
@@ -18,6 +18,11 @@ func prepareLut(lutSize int, fn func(float32) float32) []float32 {
 
 func getFromLut(lut []float32, u float32) float32 {
 	v := int(u*float32(len(lut)-1) + 0.5)
+	if v < 0 {
+		v = 0
+	} else if v > len(lut)-1 {
+		v = len(lut) - 1
+	}
 	return lut[v]
 }
 
@@ -62,19 +67,81 @@ func (p *colorchanFilter) Draw(dst draw.Image, src image.Image, options *Options
 		}
 	}
 
+	// the values out of range [0, 1] (floating point images) are not in the lut
+	apply := func(v float32) float32 {
+		if useLut && v >= 0 && v <= 1 {
+			return getFromLut(lut, v)
+		}
+		return p.fn(v)
+	}
+
 	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
 		for y := pmin; y < pmax; y++ {
 			for x := srcb.Min.X; x < srcb.Max.X; x++ {
 				px := pixGetter.getPixel(x, y)
-				if useLut {
-					px.R = getFromLut(lut, px.R)
-					px.G = getFromLut(lut, px.G)
-					px.B = getFromLut(lut, px.B)
-				} else {
-					px.R = p.fn(px.R)
-					px.G = p.fn(px.G)
-					px.B = p.fn(px.B)
-				}
+				px.R = apply(px.R)
+				px.G = apply(px.G)
+				px.B = apply(px.B)
+				pixSetter.setPixel(dstb.Min.X+x-srcb.Min.X, dstb.Min.Y+y-srcb.Min.Y, px)
+			}
+		}
+	})
+}
+
+// channelsFilter applies separate functions to the red, green, blue and alpha channels.
+// A nil function leaves the channel unchanged.
+type channelsFilter struct {
+	fns [4]func(float32) float32
+}
+
+func (p *channelsFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *channelsFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	pixGetter := newPixelGetter(src)
+	pixSetter := newPixelSetter(dst)
+
+	lutSize := 0xffff + 1
+	it := pixGetter.imgType
+	if it == itNRGBA || it == itRGBA || it == itGray || it == itYCbCr {
+		lutSize = 0xff + 1
+	}
+
+	var luts [4][]float32
+	if srcb.Dx()*srcb.Dy() > lutSize*2 {
+		for i, fn := range p.fns {
+			if fn != nil {
+				luts[i] = prepareLut(lutSize, fn)
+			}
+		}
+	}
+
+	apply := func(i int, v float32) float32 {
+		if luts[i] != nil && v >= 0 && v <= 1 {
+			return getFromLut(luts[i], v)
+		}
+		if p.fns[i] != nil {
+			return p.fns[i](v)
+		}
+		return v
+	}
+
+	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := srcb.Min.X; x < srcb.Max.X; x++ {
+				px := pixGetter.getPixel(x, y)
+				px.R = apply(0, px.R)
+				px.G = apply(1, px.G)
+				px.B = apply(2, px.B)
+				px.A = apply(3, px.A)
 				pixSetter.setPixel(dstb.Min.X+x-srcb.Min.X, dstb.Min.Y+y-srcb.Min.Y, px)
 			}
 		}
@@ -466,6 +533,75 @@ func ColorBalance(percentageRed, percentageGreen, percentageBlue float32) Filter
 	}
 }
 
+// ColorMatrix creates a filter that transforms the colors of an image using a 4x5 matrix,
+// like the SVG feColorMatrix filter. The matrix is stored row by row, the rows compute the red, green,
+// blue and alpha channels from the non-premultiplied channels of the source pixel and a constant offset:
+//
+//	r = m[0]*r0 + m[1]*g0 + m[2]*b0 + m[3]*a0 + m[4]
+//	g = m[5]*r0 + m[6]*g0 + m[7]*b0 + m[8]*a0 + m[9]
+//	b = m[10]*r0 + m[11]*g0 + m[12]*b0 + m[13]*a0 + m[14]
+//	a = m[15]*r0 + m[16]*g0 + m[17]*b0 + m[18]*a0 + m[19]
+//
+// The channel values and the offsets are in range (0, 1).
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ColorMatrix([20]float32{ // CSS sepia(100%)
+//			0.393, 0.769, 0.189, 0, 0,
+//			0.349, 0.686, 0.168, 0, 0,
+//			0.272, 0.534, 0.131, 0, 0,
+//			0, 0, 0, 1, 0,
+//		}),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func ColorMatrix(m [20]float32) Filter {
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			return pixel{
+				m[0]*px.R + m[1]*px.G + m[2]*px.B + m[3]*px.A + m[4],
+				m[5]*px.R + m[6]*px.G + m[7]*px.B + m[8]*px.A + m[9],
+				m[10]*px.R + m[11]*px.G + m[12]*px.B + m[13]*px.A + m[14],
+				m[15]*px.R + m[16]*px.G + m[17]*px.B + m[18]*px.A + m[19],
+			}
+		},
+	}
+}
+
+// MixerChannel specifies the contributions of the source channels to an output channel of the ChannelMixer filter.
+// The Red, Green and Blue fields are the percentages of the source channels, typically in range (-200, 200),
+// the Constant field is a percentage of the maximum value added to the output channel.
+type MixerChannel struct {
+	Red, Green, Blue, Constant float32
+}
+
+// ChannelMixer creates a filter that computes every color channel of an image as a weighted sum
+// of the source color channels. The alpha channel is preserved.
+// Using the same weights for all the output channels produces a grayscale image.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ChannelMixer(
+//			gift.MixerChannel{Red: 100},              // red = 100% red
+//			gift.MixerChannel{Red: 20, Green: 80},    // green = 20% red + 80% green
+//			gift.MixerChannel{Blue: 90, Constant: 5}, // blue = 90% blue + 5%
+//		),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func ChannelMixer(red, green, blue MixerChannel) Filter {
+	var m [20]float32
+	for i, c := range []MixerChannel{red, green, blue} {
+		copy(m[i*5:], []float32{c.Red / 100, c.Green / 100, c.Blue / 100, 0, c.Constant / 100})
+	}
+	m[18] = 1
+	return ColorMatrix(m)
+}
+
 // ColorFunc creates a filter that changes the colors of an image using custom function.
 // The fn parameter specifies a function that takes red, green, blue and alpha channels of a pixel
 // as float32 values in range (0, 1) and returns the modified channel values.
@@ -1081,3 +1081,92 @@ func TestColorFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestColorMatrix(t *testing.T) {
+	testData := []struct {
+		desc           string
+		m              [20]float32
+		srcPix, dstPix []uint8
+	}{
+		{
+			"identity",
+			[20]float32{
+				1, 0, 0, 0, 0,
+				0, 1, 0, 0, 0,
+				0, 0, 1, 0, 0,
+				0, 0, 0, 1, 0,
+			},
+			[]uint8{0x00, 0x10, 0x20, 0x30, 0xFF, 0x00, 0x88, 0xFF},
+			[]uint8{0x00, 0x10, 0x20, 0x30, 0xFF, 0x00, 0x88, 0xFF},
+		},
+		{
+			"swap red and blue, offset green",
+			[20]float32{
+				0, 0, 1, 0, 0,
+				0, 1, 0, 0, 0.25,
+				1, 0, 0, 0, 0,
+				0, 0, 0, 1, 0,
+			},
+			[]uint8{0x00, 0x10, 0x20, 0x30, 0xFF, 0xF0, 0x88, 0xFF},
+			[]uint8{0x20, 0x50, 0x00, 0x30, 0x88, 0xFF, 0xFF, 0xFF},
+		},
+		{
+			"luminance to alpha",
+			[20]float32{
+				0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0,
+				0.2125, 0.7154, 0.0721, 0, 0,
+			},
+			[]uint8{0xFF, 0xFF, 0xFF, 0x30, 0x00, 0xFF, 0x00, 0xFF},
+			[]uint8{0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0xB6},
+		},
+	}
+
+	for _, d := range testData {
+		src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		src.Pix = d.srcPix
+
+		f := ColorMatrix(d.m)
+		dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+		f.Draw(dst, src, nil)
+
+		if !comparePix(dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v", d.desc, dst.Pix)
+		}
+	}
+}
+
+func TestChannelMixer(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Pix = []uint8{0x40, 0x80, 0xC0, 0x80, 0xFF, 0x00, 0x00, 0xFF}
+
+	f := ChannelMixer(
+		MixerChannel{Red: 100},
+		MixerChannel{Red: 50, Green: 50},
+		MixerChannel{Blue: 50, Constant: 25},
+	)
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+
+	want := []uint8{0x40, 0x60, 0xA0, 0x80, 0xFF, 0x80, 0x40, 0xFF}
+	for i := range want {
+		if absf32(float32(dst.Pix[i])-float32(want[i])) > 1 {
+			t.Errorf("ChannelMixer failed: expected %#v got %#v", want, dst.Pix)
+			break
+		}
+	}
+
+	// monochrome mix equals grayscale
+	f = ChannelMixer(
+		MixerChannel{Red: 29.9, Green: 58.7, Blue: 11.4},
+		MixerChannel{Red: 29.9, Green: 58.7, Blue: 11.4},
+		MixerChannel{Red: 29.9, Green: 58.7, Blue: 11.4},
+	)
+	f.Draw(dst, src, nil)
+	gray := image.NewNRGBA(src.Bounds())
+	Grayscale().Draw(gray, src, nil)
+	if !comparePix(dst.Pix, gray.Pix) {
+		t.Errorf("ChannelMixer monochrome failed: expected %#v got %#v", gray.Pix, dst.Pix)
+	}
+}
@@ -0,0 +1,397 @@
+package gift
+
+import (
+	"math"
+)
+
+// ColorSpace specifies a color space used by the color space conversion filters.
+type ColorSpace int
+
+// Color space conversion filters store the components of a color space in the R, G and B channels
+// of an image, scaled to the range [0, 1] so that they survive the intermediate images of a filter chain.
+// The alpha channel is preserved.
+const (
+	// ColorSpaceHSV stores hue/360, saturation and value.
+	ColorSpaceHSV ColorSpace = iota
+	// ColorSpaceHSL stores hue/360, saturation and lightness.
+	ColorSpaceHSL
+	// ColorSpaceLab stores CIE L*a*b* (D65) components as L/100, a/255+0.5 and b/255+0.5.
+	ColorSpaceLab
+	// ColorSpaceLCh stores CIE LCh(ab) (D65) components as L/100, C/150 and h/360.
+	ColorSpaceLCh
+	// ColorSpaceXYZ stores CIE XYZ (D65) components divided by the white point, so the white is (1, 1, 1).
+	ColorSpaceXYZ
+	// ColorSpaceYCbCr stores full range BT.601 (JPEG) components as Y, Cb+0.5 and Cr+0.5.
+	ColorSpaceYCbCr
+	// ColorSpaceOklab stores Oklab components as L, a+0.5 and b+0.5.
+	ColorSpaceOklab
+)
+
+// D65 white point of the CIE XYZ color space.
+const (
+	whiteX = 0.95047
+	whiteZ = 1.08883
+)
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// Matrices converting linear sRGB to CIE XYZ (D65) and back, stored row by row.
+var (
+	linearRGBToXYZ = [9]float64{
+		0.4124564, 0.3575761, 0.1804375,
+		0.2126729, 0.7151522, 0.0721750,
+		0.0193339, 0.1191920, 0.9503041,
+	}
+	xyzToLinearRGB = [9]float64{
+		3.2404542, -1.5371385, -0.4985314,
+		-0.9692660, 1.8760108, 0.0415560,
+		0.0556434, -0.2040259, 1.0572252,
+	}
+)
+
+// mulMat3 multiplies two 3x3 matrices.
+func mulMat3(a, b [9]float64) (m [9]float64) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i*3+j] = a[i*3]*b[j] + a[i*3+1]*b[3+j] + a[i*3+2]*b[6+j]
+		}
+	}
+	return
+}
+
+// mulMat3Vec multiplies a 3x3 matrix by a vector.
+func mulMat3Vec(m [9]float64, x, y, z float64) (float64, float64, float64) {
+	return m[0]*x + m[1]*y + m[2]*z,
+		m[3]*x + m[4]*y + m[5]*z,
+		m[6]*x + m[7]*y + m[8]*z
+}
+
+// srgbToXYZ converts a color from sRGB to CIE XYZ with the D65 white point.
+func srgbToXYZ(r, g, b float64) (x, y, z float64) {
+	return mulMat3Vec(linearRGBToXYZ, srgbToLinear(r), srgbToLinear(g), srgbToLinear(b))
+}
+
+// xyzToSRGB converts a color from CIE XYZ with the D65 white point to sRGB.
+func xyzToSRGB(x, y, z float64) (r, g, b float64) {
+	r, g, b = mulMat3Vec(xyzToLinearRGB, x, y, z)
+	return linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+}
+
+// srgbToLab converts a color from sRGB to CIE L*a*b* with the D65 white point.
+func srgbToLab(r, g, b float64) (l, a, bb float64) {
+	x, y, z := srgbToXYZ(r, g, b)
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x/whiteX), f(y), f(z/whiteZ)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// labToSRGB converts a color from CIE L*a*b* with the D65 white point to sRGB.
+func labToSRGB(l, a, bb float64) (r, g, b float64) {
+	finv := func(t float64) float64 {
+		if t3 := t * t * t; t3 > 216.0/24389 {
+			return t3
+		}
+		return (116*t - 16) / (24389.0 / 27)
+	}
+	fy := (l + 16) / 116
+	fx := fy + a/500
+	fz := fy - bb/200
+	return xyzToSRGB(finv(fx)*whiteX, finv(fy), finv(fz)*whiteZ)
+}
+
+// srgbToOklab converts a color from sRGB to Oklab.
+func srgbToOklab(r, g, b float64) (l, a, bb float64) {
+	r, g, b = srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	l = 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc
+	a = 1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc
+	bb = 0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+	return
+}
+
+// oklabToSRGB converts a color from Oklab to sRGB.
+func oklabToSRGB(l, a, bb float64) (r, g, b float64) {
+	lc := l + 0.3963377774*a + 0.2158037573*bb
+	mc := l - 0.1055613458*a - 0.0638541728*bb
+	sc := l - 0.0894841775*a - 1.2914855480*bb
+	lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+	r = 4.0767416621*lc - 3.3077115913*mc + 0.2309699292*sc
+	g = -1.2684380046*lc + 2.6097574011*mc - 0.3413193965*sc
+	b = -0.0041960863*lc - 0.7034186147*mc + 1.7076147010*sc
+	return linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+}
+
+// RGBToHSV converts a color from RGB to HSV.
+// The r, g, b, s and v values are in range [0, 1], the hue h is in degrees in range [0, 360).
+func RGBToHSV(r, g, b float32) (h, s, v float32) {
+	max := maxf32(r, maxf32(g, b))
+	min := minf32(r, minf32(g, b))
+	v = max
+	if max == min {
+		return 0, 0, v
+	}
+	d := max - min
+	if max != 0 {
+		s = d / max
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, v
+}
+
+// HSVToRGB converts a color from HSV to RGB.
+// See RGBToHSV for the ranges of the values.
+func HSVToRGB(h, s, v float32) (r, g, b float32) {
+	h = normalizeHue(h/360) * 6
+	i := int(h)
+	f := h - float32(i)
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	switch i {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
+
+// RGBToHSL converts a color from RGB to HSL.
+// The r, g, b, s and l values are in range [0, 1], the hue h is in degrees in range [0, 360).
+func RGBToHSL(r, g, b float32) (h, s, l float32) {
+	h, s, l = convertRGBToHSL(r, g, b)
+	return h * 360, s, l
+}
+
+// HSLToRGB converts a color from HSL to RGB.
+// See RGBToHSL for the ranges of the values.
+func HSLToRGB(h, s, l float32) (r, g, b float32) {
+	return convertHSLToRGB(normalizeHue(h/360), s, l)
+}
+
+// RGBToXYZ converts a color from sRGB to CIE XYZ with the D65 white point.
+// The r, g and b values are in range [0, 1], the white color is converted to (0.95047, 1, 1.08883).
+func RGBToXYZ(r, g, b float32) (x, y, z float32) {
+	x64, y64, z64 := srgbToXYZ(float64(r), float64(g), float64(b))
+	return float32(x64), float32(y64), float32(z64)
+}
+
+// XYZToRGB converts a color from CIE XYZ with the D65 white point to sRGB.
+// See RGBToXYZ for the ranges of the values.
+func XYZToRGB(x, y, z float32) (r, g, b float32) {
+	r64, g64, b64 := xyzToSRGB(float64(x), float64(y), float64(z))
+	return float32(r64), float32(g64), float32(b64)
+}
+
+// RGBToLab converts a color from sRGB to CIE L*a*b* with the D65 white point.
+// The r, g and b values are in range [0, 1], the lightness l is in range [0, 100],
+// the a and b values of the sRGB colors are roughly in range [-128, 128].
+func RGBToLab(r, g, b float32) (l, a, bb float32) {
+	l64, a64, b64 := srgbToLab(float64(r), float64(g), float64(b))
+	return float32(l64), float32(a64), float32(b64)
+}
+
+// LabToRGB converts a color from CIE L*a*b* with the D65 white point to sRGB.
+// See RGBToLab for the ranges of the values.
+func LabToRGB(l, a, bb float32) (r, g, b float32) {
+	r64, g64, b64 := labToSRGB(float64(l), float64(a), float64(bb))
+	return float32(r64), float32(g64), float32(b64)
+}
+
+// RGBToLCh converts a color from sRGB to CIE LCh(ab), the cylindrical form of CIE L*a*b*.
+// The lightness l is in range [0, 100], the chroma c of the sRGB colors is in range [0, 134],
+// the hue h is in degrees in range [0, 360).
+func RGBToLCh(r, g, b float32) (l, c, h float32) {
+	l64, a64, b64 := srgbToLab(float64(r), float64(g), float64(b))
+	c64 := math.Hypot(a64, b64)
+	h64 := math.Atan2(b64, a64) * 180 / math.Pi
+	if h64 < 0 {
+		h64 += 360
+	}
+	return float32(l64), float32(c64), float32(h64)
+}
+
+// LChToRGB converts a color from CIE LCh(ab) to sRGB.
+// See RGBToLCh for the ranges of the values.
+func LChToRGB(l, c, h float32) (r, g, b float32) {
+	sin, cos := math.Sincos(float64(h) * math.Pi / 180)
+	r64, g64, b64 := labToSRGB(float64(l), float64(c)*cos, float64(c)*sin)
+	return float32(r64), float32(g64), float32(b64)
+}
+
+// RGBToYCbCr converts a color from RGB to full range BT.601 YCbCr, as used by JPEG.
+// The r, g, b and y values are in range [0, 1], the cb and cr values are in range [-0.5, 0.5].
+func RGBToYCbCr(r, g, b float32) (y, cb, cr float32) {
+	y = 0.299*r + 0.587*g + 0.114*b
+	cb = -0.168736*r - 0.331264*g + 0.5*b
+	cr = 0.5*r - 0.418688*g - 0.081312*b
+	return
+}
+
+// YCbCrToRGB converts a color from full range BT.601 YCbCr to RGB.
+// See RGBToYCbCr for the ranges of the values.
+func YCbCrToRGB(y, cb, cr float32) (r, g, b float32) {
+	r = y + 1.402*cr
+	g = y - 0.344136*cb - 0.714136*cr
+	b = y + 1.772*cb
+	return
+}
+
+// RGBToOklab converts a color from sRGB to Oklab.
+// The r, g, b and l values are in range [0, 1], the a and b values of the sRGB colors are roughly in range [-0.4, 0.4].
+func RGBToOklab(r, g, b float32) (l, a, bb float32) {
+	l64, a64, b64 := srgbToOklab(float64(r), float64(g), float64(b))
+	return float32(l64), float32(a64), float32(b64)
+}
+
+// OklabToRGB converts a color from Oklab to sRGB.
+// See RGBToOklab for the ranges of the values.
+func OklabToRGB(l, a, bb float32) (r, g, b float32) {
+	r64, g64, b64 := oklabToSRGB(float64(l), float64(a), float64(bb))
+	return float32(r64), float32(g64), float32(b64)
+}
+
+// colorSpaceFuncs returns the functions converting RGB to the scaled components of the color space and back.
+func colorSpaceFuncs(cs ColorSpace) (to, from func(c0, c1, c2 float32) (float32, float32, float32)) {
+	switch cs {
+	case ColorSpaceHSV:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			h, s, v := RGBToHSV(r, g, b)
+			return h / 360, s, v
+		}
+		from = func(h, s, v float32) (float32, float32, float32) {
+			return HSVToRGB(h*360, s, v)
+		}
+	case ColorSpaceHSL:
+		to = convertRGBToHSL
+		from = func(h, s, l float32) (float32, float32, float32) {
+			return convertHSLToRGB(normalizeHue(h), s, l)
+		}
+	case ColorSpaceLab:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			l, a, bb := RGBToLab(r, g, b)
+			return l / 100, a/255 + 0.5, bb/255 + 0.5
+		}
+		from = func(l, a, bb float32) (float32, float32, float32) {
+			return LabToRGB(l*100, (a-0.5)*255, (bb-0.5)*255)
+		}
+	case ColorSpaceLCh:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			l, c, h := RGBToLCh(r, g, b)
+			return l / 100, c / 150, h / 360
+		}
+		from = func(l, c, h float32) (float32, float32, float32) {
+			return LChToRGB(l*100, c*150, h*360)
+		}
+	case ColorSpaceXYZ:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			x, y, z := RGBToXYZ(r, g, b)
+			return x / whiteX, y, z / whiteZ
+		}
+		from = func(x, y, z float32) (float32, float32, float32) {
+			return XYZToRGB(x*whiteX, y, z*whiteZ)
+		}
+	case ColorSpaceYCbCr:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			y, cb, cr := RGBToYCbCr(r, g, b)
+			return y, cb + 0.5, cr + 0.5
+		}
+		from = func(y, cb, cr float32) (float32, float32, float32) {
+			return YCbCrToRGB(y, cb-0.5, cr-0.5)
+		}
+	case ColorSpaceOklab:
+		to = func(r, g, b float32) (float32, float32, float32) {
+			l, a, bb := RGBToOklab(r, g, b)
+			return l, a + 0.5, bb + 0.5
+		}
+		from = func(l, a, bb float32) (float32, float32, float32) {
+			return OklabToRGB(l, a-0.5, bb-0.5)
+		}
+	default:
+		to = func(c0, c1, c2 float32) (float32, float32, float32) {
+			return c0, c1, c2
+		}
+		from = to
+	}
+	return
+}
+
+// ConvertToColorSpace creates a filter that converts the colors of an image from sRGB to the specified color space.
+// The components of the color space are stored in the R, G and B channels scaled to the range [0, 1]
+// (see the ColorSpace constants), the alpha channel is preserved.
+// A giftimage.F32RGBA destination image keeps the components without the quantization,
+// so the colors can be accurately converted back with ConvertFromColorSpace.
+//
+// Example:
+//
+//	// Convert the image to Oklab.
+//	g := gift.New(
+//		gift.ConvertToColorSpace(gift.ColorSpaceOklab),
+//	)
+//	lab := giftimage.NewF32RGBA(g.Bounds(src.Bounds()))
+//	g.Draw(lab, src)
+//
+//	// Process the lab image, then convert it back to sRGB.
+//	g = gift.New(
+//		gift.ConvertFromColorSpace(gift.ColorSpaceOklab),
+//	)
+//	dst := image.NewNRGBA(g.Bounds(lab.Bounds()))
+//	g.Draw(dst, lab)
+//
+func ConvertToColorSpace(cs ColorSpace) Filter {
+	to, _ := colorSpaceFuncs(cs)
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			c0, c1, c2 := to(px.R, px.G, px.B)
+			return pixel{c0, c1, c2, px.A}
+		},
+	}
+}
+
+// ConvertFromColorSpace creates a filter that converts the colors of an image from the specified color space to sRGB.
+// It reverses the ConvertToColorSpace filter.
+func ConvertFromColorSpace(cs ColorSpace) Filter {
+	_, from := colorSpaceFuncs(cs)
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			r, g, b := from(px.R, px.G, px.B)
+			return pixel{r, g, b, px.A}
+		},
+	}
+}
@@ -0,0 +1,145 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+func TestColorSpaceConverters(t *testing.T) {
+	near := func(a, b, eps float32) bool {
+		return math.Abs(float64(a-b)) <= float64(eps)
+	}
+
+	testData := []struct {
+		desc       string
+		to         func(r, g, b float32) (float32, float32, float32)
+		r, g, b    float32
+		c0, c1, c2 float32
+		eps        float32
+	}{
+		{"hsv orange", RGBToHSV, 1, 0.5, 0, 30, 1, 1, 1e-4},
+		{"hsv gray", RGBToHSV, 0.5, 0.5, 0.5, 0, 0, 0.5, 1e-4},
+		{"hsv magenta", RGBToHSV, 0.8, 0, 0.4, 330, 1, 0.8, 1e-3},
+		{"hsl orange", RGBToHSL, 1, 0.5, 0, 30, 1, 0.5, 1e-4},
+		{"xyz white", RGBToXYZ, 1, 1, 1, 0.95047, 1, 1.08883, 1e-4},
+		{"lab white", RGBToLab, 1, 1, 1, 100, 0, 0, 1e-3},
+		{"lab red", RGBToLab, 1, 0, 0, 53.24, 80.09, 67.20, 0.01},
+		{"lch red", RGBToLCh, 1, 0, 0, 53.24, 104.55, 40.00, 0.01},
+		{"ycbcr white", RGBToYCbCr, 1, 1, 1, 1, 0, 0, 1e-4},
+		{"ycbcr blue", RGBToYCbCr, 0, 0, 1, 0.114, 0.5, -0.081312, 1e-4},
+		{"oklab white", RGBToOklab, 1, 1, 1, 1, 0, 0, 1e-4},
+		{"oklab red", RGBToOklab, 1, 0, 0, 0.62796, 0.22486, 0.12585, 1e-4},
+	}
+
+	for _, d := range testData {
+		c0, c1, c2 := d.to(d.r, d.g, d.b)
+		if !near(c0, d.c0, d.eps) || !near(c1, d.c1, d.eps) || !near(c2, d.c2, d.eps) {
+			t.Errorf("test [%s] failed: expected (%v, %v, %v) got (%v, %v, %v)", d.desc, d.c0, d.c1, d.c2, c0, c1, c2)
+		}
+	}
+}
+
+func TestColorSpaceRoundTrip(t *testing.T) {
+	pairs := []struct {
+		desc     string
+		to, from func(c0, c1, c2 float32) (float32, float32, float32)
+	}{
+		{"hsv", RGBToHSV, HSVToRGB},
+		{"hsl", RGBToHSL, HSLToRGB},
+		{"xyz", RGBToXYZ, XYZToRGB},
+		{"lab", RGBToLab, LabToRGB},
+		{"lch", RGBToLCh, LChToRGB},
+		{"ycbcr", RGBToYCbCr, YCbCrToRGB},
+		{"oklab", RGBToOklab, OklabToRGB},
+	}
+
+	for _, p := range pairs {
+		for r := float32(0); r <= 1; r += 0.125 {
+			for g := float32(0); g <= 1; g += 0.125 {
+				for b := float32(0); b <= 1; b += 0.125 {
+					r1, g1, b1 := p.from(p.to(r, g, b))
+					if absf32(r-r1) > 1e-4 || absf32(g-g1) > 1e-4 || absf32(b-b1) > 1e-4 {
+						t.Errorf("test [%s] failed: expected (%v, %v, %v) got (%v, %v, %v)", p.desc, r, g, b, r1, g1, b1)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestColorSpaceFilters(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x * 17), uint8(y * 17), uint8((x + y) * 8), uint8(255 - x*4)})
+		}
+	}
+
+	spaces := []ColorSpace{
+		ColorSpaceHSV,
+		ColorSpaceHSL,
+		ColorSpaceLab,
+		ColorSpaceLCh,
+		ColorSpaceXYZ,
+		ColorSpaceYCbCr,
+		ColorSpaceOklab,
+	}
+
+	for _, cs := range spaces {
+		// float image in between
+		g := New(ConvertToColorSpace(cs))
+		tmp := giftimage.NewF32RGBA(g.Bounds(src.Bounds()))
+		g.Draw(tmp, src)
+		for i, v := range tmp.Pix {
+			if v < 0 || v > 1 {
+				t.Errorf("color space %d: value %v at index %d out of range [0, 1]", cs, v, i)
+				break
+			}
+		}
+		g = New(ConvertFromColorSpace(cs))
+		dst := image.NewNRGBA(g.Bounds(tmp.Bounds()))
+		g.Draw(dst, tmp)
+		if !comparePix(src.Pix, dst.Pix) {
+			t.Errorf("color space %d: float round trip failed", cs)
+		}
+
+		// 16-bit intermediate images of a filter chain
+		g = New(ConvertToColorSpace(cs), ConvertFromColorSpace(cs))
+		dst = image.NewNRGBA(g.Bounds(src.Bounds()))
+		g.Draw(dst, src)
+		for i := range src.Pix {
+			if absf32(float32(src.Pix[i])-float32(dst.Pix[i])) > 1 {
+				t.Errorf("color space %d: chain round trip failed at index %d: expected %d got %d", cs, i, src.Pix[i], dst.Pix[i])
+				break
+			}
+		}
+	}
+}
+
+func TestColorSpaceLightness(t *testing.T) {
+	// Changing only the lightness channel must keep the gray colors gray.
+	src := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(src.Pix, []uint8{0x00, 0x40, 0x80, 0xff})
+	g := New(
+		ConvertToColorSpace(ColorSpaceLab),
+		ColorFunc(func(r0, g0, b0, a0 float32) (r, g, b, a float32) {
+			return r0 * 0.5, g0, b0, a0
+		}),
+		ConvertFromColorSpace(ColorSpaceLab),
+	)
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	for i := 0; i < 4; i++ {
+		c := dst.NRGBAAt(i, 0)
+		if !compareColorsNRGBA(c, color.NRGBA{c.R, c.R, c.R, 0xff}, 1) {
+			t.Errorf("gray color %d changed to %v", i, c)
+		}
+	}
+	if c := dst.NRGBAAt(3, 0); c.R < 0x70 || c.R > 0x78 {
+		t.Errorf("L*=50 expected about 0x76 got %v", c)
+	}
+}
@@ -337,16 +337,29 @@ func (p *gausssianBlurFilter) Draw(dst draw.Image, src image.Image, options *Opt
 		return
 	}
 
-	radius := int(math.Ceil(float64(p.sigma * 3)))
+	kernel := gaussianBlurKernel1d(p.sigma)
+
+	tmp := createTempImageFor(srcb, src, dst)
+	convolve1dh(tmp, src, kernel, options)
+	convolve1dv(dst, tmp, kernel, options)
+}
+
+// gaussianBlurKernel1d returns the normalized 1d gaussian kernel, or nil if sigma is not positive.
+func gaussianBlurKernel1d(sigma float32) []float32 {
+	if sigma <= 0 {
+		return nil
+	}
+
+	radius := int(math.Ceil(float64(sigma * 3)))
 	size := 2*radius + 1
 	center := radius
 	kernel := make([]float32, size)
 
-	kernel[center] = gaussianBlurKernel(0, p.sigma)
+	kernel[center] = gaussianBlurKernel(0, sigma)
 	sum := kernel[center]
 
 	for i := 1; i <= radius; i++ {
-		f := gaussianBlurKernel(float32(i), p.sigma)
+		f := gaussianBlurKernel(float32(i), sigma)
 		kernel[center-i] = f
 		kernel[center+i] = f
 		sum += 2 * f
@@ -356,9 +369,7 @@ func (p *gausssianBlurFilter) Draw(dst draw.Image, src image.Image, options *Opt
 		kernel[i] /= sum
 	}
 
-	tmp := createTempImage(srcb)
-	convolve1dh(tmp, src, kernel, options)
-	convolve1dv(dst, tmp, kernel, options)
+	return kernel
 }
 
 // GaussianBlur creates a filter that applies a gaussian blur to an image.
@@ -410,7 +421,7 @@ func (p *unsharpMaskFilter) Draw(dst draw.Image, src image.Image, options *Optio
 		return
 	}
 
-	blurred := createTempImage(srcb)
+	blurred := createTempImageFor(srcb, src, dst)
 	blur := GaussianBlur(p.sigma)
 	blur.Draw(blurred, src, options)
 
@@ -533,11 +544,11 @@ func (p *hvConvolutionFilter) Draw(dst draw.Image, src image.Image, options *Opt
 		return
 	}
 
-	tmph := createTempImage(srcb)
+	tmph := createTempImageFor(srcb, src, dst)
 	Convolution(p.hkernel, false, false, true, 0).Draw(tmph, src, options)
 	pixGetterH := newPixelGetter(tmph)
 
-	tmpv := createTempImage(srcb)
+	tmpv := createTempImageFor(srcb, src, dst)
 	Convolution(p.vkernel, false, false, true, 0).Draw(tmpv, src, options)
 	pixGetterV := newPixelGetter(tmpv)
 
@@ -0,0 +1,141 @@
+package gift
+
+import (
+	"math"
+	"sort"
+)
+
+// CurvePoint is a control point of a tone curve.
+// In is the input value and Out is the output value, both in the range [0, 1].
+type CurvePoint struct {
+	In, Out float32
+}
+
+// monotoneCubic returns the function that interpolates the control points using
+// the monotone cubic (Fritsch-Carlson) spline. The curve is constant outside of the control points.
+// It returns nil if there are no control points.
+func monotoneCubic(points []CurvePoint) func(float32) float32 {
+	if len(points) == 0 {
+		return nil
+	}
+
+	pts := append([]CurvePoint(nil), points...)
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].In < pts[j].In })
+	xs := make([]float64, 0, len(pts))
+	ys := make([]float64, 0, len(pts))
+	for _, pt := range pts {
+		x := float64(minf32(maxf32(pt.In, 0), 1))
+		y := float64(minf32(maxf32(pt.Out, 0), 1))
+		if n := len(xs); n > 0 && x == xs[n-1] {
+			ys[n-1] = y
+			continue
+		}
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+
+	n := len(xs)
+	if n == 1 {
+		y := float32(ys[0])
+		return func(float32) float32 { return y }
+	}
+
+	// secant slopes and initial tangents
+	d := make([]float64, n-1)
+	for i := range d {
+		d[i] = (ys[i+1] - ys[i]) / (xs[i+1] - xs[i])
+	}
+	m := make([]float64, n)
+	m[0], m[n-1] = d[0], d[n-2]
+	for i := 1; i < n-1; i++ {
+		if d[i-1]*d[i] <= 0 {
+			m[i] = 0
+		} else {
+			m[i] = (d[i-1] + d[i]) / 2
+		}
+	}
+
+	// limit the tangents to preserve monotonicity
+	for i := range d {
+		if d[i] == 0 {
+			m[i], m[i+1] = 0, 0
+			continue
+		}
+		a, b := m[i]/d[i], m[i+1]/d[i]
+		if s := a*a + b*b; s > 9 {
+			t := 3 / math.Sqrt(s)
+			m[i] = t * a * d[i]
+			m[i+1] = t * b * d[i]
+		}
+	}
+
+	return func(v float32) float32 {
+		x := float64(v)
+		if x <= xs[0] {
+			return float32(ys[0])
+		}
+		if x >= xs[n-1] {
+			return float32(ys[n-1])
+		}
+		i := sort.SearchFloat64s(xs, x) - 1
+		h := xs[i+1] - xs[i]
+		t := (x - xs[i]) / h
+		t2, t3 := t*t, t*t*t
+		y := (2*t3-3*t2+1)*ys[i] + (t3-2*t2+t)*h*m[i] + (-2*t3+3*t2)*ys[i+1] + (t3-t2)*h*m[i+1]
+		return minf32(maxf32(float32(y), 0), 1)
+	}
+}
+
+// Curves creates a filter that adjusts the tones of an image using a curve that passes through
+// the given control points. The curve is applied to the red, green and blue channels.
+// The control points are interpolated using a monotone cubic spline.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Curves([]gift.CurvePoint{{0, 0}, {0.25, 0.2}, {0.75, 0.85}, {1, 1}}), // S-curve
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Curves(points []CurvePoint) Filter {
+	return ChannelCurves(points, nil, nil, nil, nil)
+}
+
+// ChannelCurves creates a filter that adjusts the tones of an image using separate curves for
+// every channel. The red, green and blue curves are applied first, followed by the master curve
+// that is applied to all the color channels. Nil or empty control points leave the channel unchanged.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ChannelCurves(
+//			nil,                                  // master
+//			[]gift.CurvePoint{{0, 0.1}, {1, 1}},  // red
+//			nil,                                  // green
+//			[]gift.CurvePoint{{0, 0}, {1, 0.9}},  // blue
+//			nil,                                  // alpha
+//		),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func ChannelCurves(master, red, green, blue, alpha []CurvePoint) Filter {
+	fm := monotoneCubic(master)
+	fns := [4]func(float32) float32{monotoneCubic(red), monotoneCubic(green), monotoneCubic(blue), monotoneCubic(alpha)}
+	if fm != nil {
+		for i := 0; i < 3; i++ {
+			fc := fns[i]
+			if fc == nil {
+				fns[i] = fm
+				continue
+			}
+			fns[i] = func(x float32) float32 {
+				return fm(fc(x))
+			}
+		}
+	}
+	return &channelsFilter{
+		fns: fns,
+	}
+}
@@ -0,0 +1,95 @@
+package gift
+
+import (
+	"image"
+	"testing"
+)
+
+func TestMonotoneCubic(t *testing.T) {
+	if fn := monotoneCubic(nil); fn != nil {
+		t.Error("monotoneCubic: expected nil for empty points")
+	}
+	if v := monotoneCubic([]CurvePoint{{0.3, 0.7}})(0.9); v != 0.7 {
+		t.Errorf("monotoneCubic: single point: expected 0.7 got %v", v)
+	}
+
+	points := []CurvePoint{{0.75, 0.85}, {0.1, 0}, {0.25, 0.2}, {0.5, 0.5}, {0.9, 1}}
+	fn := monotoneCubic(points)
+	for _, pt := range points {
+		if v := fn(pt.In); absf32(v-pt.Out) > 1e-6 {
+			t.Errorf("monotoneCubic: at %v: expected %v got %v", pt.In, pt.Out, v)
+		}
+	}
+	if fn(0) != 0 || fn(0.05) != 0 || fn(0.95) != 1 {
+		t.Error("monotoneCubic: curve must be constant outside of the control points")
+	}
+	prev := fn(0)
+	for i := 1; i <= 1000; i++ {
+		v := fn(float32(i) / 1000)
+		if v < prev {
+			t.Errorf("monotoneCubic: curve is not monotone at %v", float32(i)/1000)
+			break
+		}
+		prev = v
+	}
+
+	identity := monotoneCubic([]CurvePoint{{0, 0}, {1, 1}})
+	for _, x := range []float32{0, 0.2, 0.5, 0.77, 1} {
+		if v := identity(x); absf32(v-x) > 1e-6 {
+			t.Errorf("monotoneCubic: identity: expected %v got %v", x, v)
+		}
+	}
+}
+
+func TestCurves(t *testing.T) {
+	testData := []struct {
+		desc           string
+		filter         Filter
+		srcPix, dstPix []uint8
+	}{
+		{
+			"curves invert",
+			Curves([]CurvePoint{{0, 1}, {1, 0}}),
+			[]uint8{0x00, 0x40, 0xff, 0x80},
+			[]uint8{0xff, 0xbf, 0x00, 0x7f},
+		},
+		{
+			"curves empty",
+			Curves(nil),
+			[]uint8{0x00, 0x40, 0xff, 0x80},
+			[]uint8{0x00, 0x40, 0xff, 0x80},
+		},
+		{
+			"curves clip",
+			Curves([]CurvePoint{{0.25, 0}, {0.75, 1}}),
+			[]uint8{0x00, 0x40, 0x80, 0xc0, 0xff},
+			[]uint8{0x00, 0x01, 0x81, 0xff, 0xff},
+		},
+	}
+
+	for _, d := range testData {
+		src := image.NewGray(image.Rect(0, 0, len(d.srcPix), 1))
+		copy(src.Pix, d.srcPix)
+		dst := image.NewGray(src.Bounds())
+		New(d.filter).Draw(dst, src)
+		if !comparePix(dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v, %#v", d.desc, dst.Pix, d.dstPix)
+		}
+	}
+
+	// the channel curves are applied before the master curve
+	src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	copy(src.Pix, []uint8{0x40, 0x40, 0x40, 0x80})
+	f := ChannelCurves(
+		[]CurvePoint{{0, 1}, {1, 0}},
+		[]CurvePoint{{0, 0}, {1, 0}},
+		nil,
+		[]CurvePoint{{0, 1}, {1, 1}},
+		[]CurvePoint{{0, 1}, {1, 1}},
+	)
+	dst := image.NewNRGBA(src.Bounds())
+	New(f).Draw(dst, src)
+	if !comparePix(dst.Pix, []uint8{0xff, 0xbf, 0x00, 0xff}) {
+		t.Errorf("ChannelCurves: unexpected result %#v", dst.Pix)
+	}
+}
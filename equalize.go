@@ -0,0 +1,262 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// EqualizeMode specifies the channels processed by the histogram equalization filters.
+type EqualizeMode int
+
+// Histogram equalization modes.
+const (
+	// EqualizeLuminance equalizes the luminance of an image preserving the colors.
+	EqualizeLuminance EqualizeMode = iota
+	// EqualizePerChannel equalizes the red, green and blue channels independently.
+	EqualizePerChannel
+)
+
+// claheBins is the number of histogram bins used by CLAHE.
+const claheBins = 256
+
+// equalizeGlobal maps the values using the cumulative histogram of all the values.
+func equalizeGlobal(vals []float32, bins int) {
+	hist := make([]int, bins)
+	for _, v := range vals {
+		hist[histogramBin(v, bins)]++
+	}
+	cdfMin := 0
+	for _, n := range hist {
+		if n > 0 {
+			cdfMin = n
+			break
+		}
+	}
+	lut := make([]float32, bins)
+	d := len(vals) - cdfMin
+	for i, sum := 0, 0; i < bins; i++ {
+		sum += hist[i]
+		if d > 0 {
+			lut[i] = float32(sum-cdfMin) / float32(d)
+		} else {
+			lut[i] = float32(i) / float32(bins-1)
+		}
+	}
+	for i, v := range vals {
+		vals[i] = lut[histogramBin(v, bins)]
+	}
+}
+
+// equalizeAdaptive maps the values using contrast limited histograms of the tiles,
+// bilinearly interpolated between the tile centers.
+func equalizeAdaptive(vals []float32, w, h, tilesX, tilesY int, clipLimit float32, options *Options) {
+	tilesX = minint(maxint(tilesX, 1), w)
+	tilesY = minint(maxint(tilesY, 1), h)
+	tileW := (w + tilesX - 1) / tilesX
+	tileH := (h + tilesY - 1) / tilesY
+	tilesX = (w + tileW - 1) / tileW
+	tilesY = (h + tileH - 1) / tileH
+
+	luts := make([][]float32, tilesX*tilesY)
+	parallelize(options.Parallelization, 0, len(luts), func(pmin, pmax int) {
+		hist := make([]int, claheBins)
+		for t := pmin; t < pmax; t++ {
+			x0, y0 := (t%tilesX)*tileW, (t/tilesX)*tileH
+			x1, y1 := minint(x0+tileW, w), minint(y0+tileH, h)
+			for i := range hist {
+				hist[i] = 0
+			}
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					hist[histogramBin(vals[y*w+x], claheBins)]++
+				}
+			}
+			area := (x1 - x0) * (y1 - y0)
+
+			if clipLimit > 0 {
+				limit := int(math.Ceil(float64(clipLimit) * float64(area) / claheBins))
+				excess := 0
+				for i, n := range hist {
+					if n > limit {
+						excess += n - limit
+						hist[i] = limit
+					}
+				}
+				add, rest := excess/claheBins, excess%claheBins
+				for i := range hist {
+					hist[i] += add
+					if i < rest {
+						hist[i]++
+					}
+				}
+			}
+
+			lut := make([]float32, claheBins)
+			for i, sum := 0, 0; i < claheBins; i++ {
+				sum += hist[i]
+				lut[i] = float32(sum) / float32(area)
+			}
+			luts[t] = lut
+		}
+	})
+
+	// tile index and interpolation weight of the coordinate
+	interp := func(c, size, n int) (i0, i1 int, a float32) {
+		f := (float32(c)+0.5)/float32(size) - 0.5
+		if f <= 0 {
+			return 0, 0, 0
+		}
+		i0 = int(f)
+		if i0 >= n-1 {
+			return n - 1, n - 1, 0
+		}
+		return i0, i0 + 1, f - float32(i0)
+	}
+
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			ty0, ty1, ay := interp(y, tileH, tilesY)
+			for x := 0; x < w; x++ {
+				tx0, tx1, ax := interp(x, tileW, tilesX)
+				b := histogramBin(vals[y*w+x], claheBins)
+				v00 := luts[ty0*tilesX+tx0][b]
+				v01 := luts[ty0*tilesX+tx1][b]
+				v10 := luts[ty1*tilesX+tx0][b]
+				v11 := luts[ty1*tilesX+tx1][b]
+				top := v00 + (v01-v00)*ax
+				bottom := v10 + (v11-v10)*ax
+				vals[y*w+x] = top + (bottom-top)*ay
+			}
+		}
+	})
+}
+
+type equalizeFilter struct {
+	mode           EqualizeMode
+	adaptive       bool
+	tilesX, tilesY int
+	clipLimit      float32
+}
+
+func (p *equalizeFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *equalizeFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	w, h := srcb.Dx(), srcb.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	pixGetter := newPixelGetter(src)
+	pixSetter := newPixelSetter(dst)
+	pixels := make([]pixel, w*h)
+	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := srcb.Min.X; x < srcb.Max.X; x++ {
+				pixels[(y-srcb.Min.Y)*w+x-srcb.Min.X] = pixGetter.getPixel(x, y)
+			}
+		}
+	})
+
+	nch := 3
+	if p.mode == EqualizeLuminance {
+		nch = 1
+	}
+	chans := make([][]float32, nch)
+	for c := range chans {
+		chans[c] = make([]float32, w*h)
+	}
+	for i, px := range pixels {
+		if p.mode == EqualizeLuminance {
+			chans[0][i] = 0.299*px.R + 0.587*px.G + 0.114*px.B
+		} else {
+			chans[0][i], chans[1][i], chans[2][i] = px.R, px.G, px.B
+		}
+	}
+
+	var orig []float32
+	if p.mode == EqualizeLuminance {
+		orig = append([]float32(nil), chans[0]...)
+	}
+	for _, vals := range chans {
+		if p.adaptive {
+			equalizeAdaptive(vals, w, h, p.tilesX, p.tilesY, p.clipLimit, options)
+		} else {
+			equalizeGlobal(vals, histogramBins(src))
+		}
+	}
+
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				i := y*w + x
+				px := pixels[i]
+				if p.mode == EqualizeLuminance {
+					// shifting all the channels by the same amount keeps the chroma
+					d := chans[0][i] - orig[i]
+					px.R = minf32(maxf32(px.R+d, 0), 1)
+					px.G = minf32(maxf32(px.G+d, 0), 1)
+					px.B = minf32(maxf32(px.B+d, 0), 1)
+				} else {
+					px.R, px.G, px.B = chans[0][i], chans[1][i], chans[2][i]
+				}
+				pixSetter.setPixel(dstb.Min.X+x, dstb.Min.Y+y, px)
+			}
+		}
+	})
+}
+
+// Equalize creates a filter that performs the histogram equalization of an image,
+// spreading the most frequent values over the whole tonal range.
+//
+// Supported modes: EqualizeLuminance, EqualizePerChannel.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Equalize(gift.EqualizeLuminance),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Equalize(mode EqualizeMode) Filter {
+	return &equalizeFilter{
+		mode: mode,
+	}
+}
+
+// CLAHE creates a filter that performs the contrast limited adaptive histogram equalization of an image.
+// The image is divided into a grid of tilesX by tilesY tiles that are equalized separately,
+// the results are bilinearly interpolated between the tile centers.
+// The clipLimit parameter limits the contrast amplification: the histogram bins higher than
+// clipLimit times the average bin height are clipped and the excess is redistributed over all the bins.
+// Typical values are in range [2, 4], the clipLimit = 0 disables the clipping.
+//
+// Supported modes: EqualizeLuminance, EqualizePerChannel.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.CLAHE(8, 8, 3, gift.EqualizeLuminance),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func CLAHE(tilesX, tilesY int, clipLimit float32, mode EqualizeMode) Filter {
+	return &equalizeFilter{
+		mode:      mode,
+		adaptive:  true,
+		tilesX:    tilesX,
+		tilesY:    tilesY,
+		clipLimit: clipLimit,
+	}
+}
@@ -0,0 +1,109 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEqualize(t *testing.T) {
+	testData := []struct {
+		desc           string
+		filter         Filter
+		srcPix, dstPix []uint8
+	}{
+		{
+			"equalize",
+			Equalize(EqualizeLuminance),
+			[]uint8{0x10, 0x20, 0x30, 0x40},
+			[]uint8{0x00, 0x55, 0xaa, 0xff},
+		},
+		{
+			"equalize repeated values",
+			Equalize(EqualizePerChannel),
+			[]uint8{0x10, 0x10, 0x10, 0x40, 0x80},
+			[]uint8{0x00, 0x00, 0x00, 0x80, 0xff},
+		},
+		{
+			"equalize flat",
+			Equalize(EqualizeLuminance),
+			[]uint8{0x30, 0x30},
+			[]uint8{0x30, 0x30},
+		},
+		{
+			"clahe single tile",
+			CLAHE(1, 1, 0, EqualizeLuminance),
+			[]uint8{0x10, 0x20, 0x30, 0x40},
+			[]uint8{0x40, 0x80, 0xbf, 0xff},
+		},
+		{
+			"clahe clip limit",
+			CLAHE(1, 1, 64, EqualizePerChannel),
+			[]uint8{0x10, 0x10, 0x10, 0x10},
+			[]uint8{0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			"clahe clipped",
+			CLAHE(1, 1, 1, EqualizePerChannel),
+			[]uint8{0x00, 0x00, 0x00, 0x00},
+			[]uint8{0x80, 0x80, 0x80, 0x80},
+		},
+	}
+
+	for _, d := range testData {
+		src := image.NewGray(image.Rect(0, 0, len(d.srcPix), 1))
+		copy(src.Pix, d.srcPix)
+		dst := image.NewGray(src.Bounds())
+		New(d.filter).Draw(dst, src)
+		if !comparePix(dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v, %#v", d.desc, dst.Pix, d.dstPix)
+		}
+	}
+}
+
+func TestCLAHE(t *testing.T) {
+	// two halves with low contrast at different brightness levels
+	src := image.NewNRGBA(image.Rect(-3, 2, 61, 34))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(0x20 + (x+y)%8)
+			if x >= 32 {
+				v += 0xa0
+			}
+			src.SetNRGBA(src.Rect.Min.X+x, src.Rect.Min.Y+y, color.NRGBA{v, v, v, 0x80})
+		}
+	}
+
+	// the original contrast of the halves is 7
+	contrast := func(img *image.NRGBA, x0, x1 int) int {
+		lo, hi := 255, 0
+		for y := 8; y < 24; y++ {
+			for x := x0; x < x1; x++ {
+				v := int(img.NRGBAAt(x, y).R)
+				lo, hi = minint(lo, v), maxint(hi, v)
+			}
+		}
+		return hi - lo
+	}
+
+	for _, mode := range []EqualizeMode{EqualizeLuminance, EqualizePerChannel} {
+		for _, prlz := range []bool{true, false} {
+			g := New(CLAHE(4, 2, 3, mode))
+			g.SetParallelization(prlz)
+			dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+			g.Draw(dst, src)
+			if c := contrast(dst, 4, 12); c < 21 {
+				t.Errorf("CLAHE %d: expected increased contrast of the dark half got %d", mode, c)
+			}
+			if c := contrast(dst, 52, 60); c < 21 {
+				t.Errorf("CLAHE %d: expected increased contrast of the bright half got %d", mode, c)
+			}
+			for i := 0; i < len(dst.Pix); i += 4 {
+				if dst.Pix[i] != dst.Pix[i+1] || dst.Pix[i] != dst.Pix[i+2] || dst.Pix[i+3] != 0x80 {
+					t.Errorf("CLAHE %d: unexpected pixel %v", mode, dst.Pix[i:i+4])
+					break
+				}
+			}
+		}
+	}
+}
@@ -68,3 +68,41 @@ var _ Filter = &discreteFourierTransform{}
 func DiscreteFourierTransform() Filter {
 	return new(discreteFourierTransform)
 }
+
+// dct2d calculates the orthonormal 2D discrete cosine transform (DCT-II) of the n x n matrix.
+// The transform is separable: the rows are transformed first, then the columns.
+func dct2d(src []float64, n int) []float64 {
+	// cosine table: c[k*n+i] = s(k) * cos(pi * (2i + 1) * k / 2n)
+	c := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		s := math.Sqrt(2 / float64(n))
+		if k == 0 {
+			s = math.Sqrt(1 / float64(n))
+		}
+		for i := 0; i < n; i++ {
+			c[k*n+i] = s * math.Cos(math.Pi*float64(2*i+1)*float64(k)/float64(2*n))
+		}
+	}
+
+	tmp := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += src[y*n+i] * c[k*n+i]
+			}
+			tmp[y*n+k] = sum
+		}
+	}
+	dst := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += tmp[i*n+x] * c[k*n+i]
+			}
+			dst[k*n+x] = sum
+		}
+	}
+	return dst
+}
@@ -25,7 +25,12 @@ package gift
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
+	"reflect"
+
+	giftimage "github.com/disintegration/gift/image"
+	giftcolor "github.com/disintegration/gift/image/color"
 )
 
 // Filter is an image processing filter.
@@ -39,6 +44,8 @@ type Filter interface {
 // Options is the parameters passed to image processing filters.
 type Options struct {
 	Parallelization bool
+	// Dither is the dithering method used when the destination image is paletted.
+	Dither DitherMethod
 }
 
 var defaultOptions = Options{
@@ -105,8 +112,21 @@ func (g *GIFT) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
 	return
 }
 
+// SetDither sets the dithering method used to draw to paletted images.
+// No dithering is used by default, every pixel gets the nearest color of the palette.
+func (g *GIFT) SetDither(method DitherMethod) {
+	g.Options.Dither = method
+}
+
 // Draw applies all the added filters to the src image and outputs the result to the dst image.
 func (g *GIFT) Draw(dst draw.Image, src image.Image) {
+	if pd, ok := dst.(*image.Paletted); ok && g.Options.Dither != NoDither && len(pd.Palette) > 0 {
+		tmp := createTempImage(g.Bounds(src.Bounds()))
+		g.Draw(tmp, src)
+		ditherImage(dst, tmp, convertPalette(pd.Palette), g.Options.Dither, &g.Options)
+		return
+	}
+
 	if len(g.Filters) == 0 {
 		copyimage(dst, src, &g.Options)
 		return
@@ -126,13 +146,98 @@ func (g *GIFT) Draw(dst draw.Image, src image.Image) {
 		if i == last {
 			tmpOut = dst
 		} else {
-			tmpOut = createTempImage(f.Bounds(tmpIn.Bounds()))
+			tmpOut = createTempImageFor(f.Bounds(tmpIn.Bounds()), src, dst)
 		}
 
 		f.Draw(tmpOut, tmpIn, &g.Options)
 	}
 }
 
+// Apply applies all the added filters to the src image and returns the result as a new image.
+// The type of the new image preserves the color model and the bit depth of the src image:
+// Gray, Gray16, Alpha, Alpha16, RGBA, RGBA64, NRGBA, NRGBA64, CMYK and the floating point
+// images of the giftimage package produce an image of the same type,
+// YCbCr images are processed by the ApplyYCbCr method.
+// Paletted images produce an NRGBA image, as the filters may generate colors that are not in the palette;
+// use ApplyModel with the palette to get a paletted image.
+// Images of the other types produce an NRGBA64 image.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Resize(800, 0, gift.LanczosResampling),
+//	)
+//	dst := g.Apply(src) // dst is *image.Gray16 if src is *image.Gray16
+//
+func (g *GIFT) Apply(src image.Image) image.Image {
+	switch src := src.(type) {
+	case *image.YCbCr:
+		return g.ApplyYCbCr(src)
+	case *image.Paletted:
+		return g.ApplyModel(src, color.NRGBAModel)
+	}
+	return g.ApplyModel(src, src.ColorModel())
+}
+
+// ApplyModel applies all the added filters to the src image and returns the result
+// as a new image with the given color model.
+// The standard color models of the image/color package (except NYCbCrAModel) and the models
+// of the giftcolor package are supported. A color.Palette produces a paletted image
+// with the given palette. YCbCrModel produces a 4:4:4 YCbCr image unless the src is a YCbCr image,
+// in which case the subsample ratio of the src is kept.
+// Other models produce an NRGBA64 image.
+func (g *GIFT) ApplyModel(src image.Image, model color.Model) image.Image {
+	b := g.Bounds(src.Bounds())
+	if model == color.YCbCrModel {
+		if src, ok := src.(*image.YCbCr); ok {
+			return g.ApplyYCbCr(src)
+		}
+		tmp := image.NewNRGBA(b)
+		g.Draw(tmp, src)
+		return convertToYCbCr(tmp, image.YCbCrSubsampleRatio444, &g.Options)
+	}
+	dst := newImageWithModel(model, b)
+	g.Draw(dst, src)
+	return dst
+}
+
+// newImageWithModel creates a new image of the type corresponding to the color model.
+func newImageWithModel(model color.Model, r image.Rectangle) draw.Image {
+	if p, ok := model.(color.Palette); ok {
+		return image.NewPaletted(r, p)
+	}
+	if !reflect.TypeOf(model).Comparable() {
+		return image.NewNRGBA64(r)
+	}
+	switch model {
+	case color.GrayModel:
+		return image.NewGray(r)
+	case color.Gray16Model:
+		return image.NewGray16(r)
+	case color.AlphaModel:
+		return image.NewAlpha(r)
+	case color.Alpha16Model:
+		return image.NewAlpha16(r)
+	case color.RGBAModel:
+		return image.NewRGBA(r)
+	case color.RGBA64Model:
+		return image.NewRGBA64(r)
+	case color.NRGBAModel:
+		return image.NewNRGBA(r)
+	case color.CMYKModel:
+		return image.NewCMYK(r)
+	case giftcolor.F32RGBAModel:
+		return giftimage.NewF32RGBA(r)
+	case giftcolor.F64RGBAModel:
+		return giftimage.NewF64RGBA(r)
+	case giftcolor.C64RGBAModel:
+		return giftimage.NewC64RGBA(r)
+	case giftcolor.C128RGBAModel:
+		return giftimage.NewC128RGBA(r)
+	}
+	return image.NewNRGBA64(r)
+}
+
 // Operator is an image composition operator.
 type Operator int
 
@@ -144,12 +249,30 @@ const (
 
 // DrawAt applies all the added filters to the src image and outputs the result to the dst image
 // at the specified position pt using the specified composition operator op.
+// If dst is a paletted image, the dithering method set by SetDither is used, as in the Draw method.
 func (g *GIFT) DrawAt(dst draw.Image, src image.Image, pt image.Point, op Operator) {
+	if pd, ok := dst.(*image.Paletted); ok && g.Options.Dither != NoDither && len(pd.Palette) > 0 {
+		tb := g.Bounds(src.Bounds())
+		tb = tb.Sub(tb.Min).Add(pt)
+		ib := tb.Intersect(pd.Bounds())
+		if ib.Empty() {
+			return
+		}
+		// compose the result in a temp image, then dither the covered area of dst
+		tmp := image.NewNRGBA64(tb)
+		if op == OverOperator {
+			copyimage(tmp.SubImage(ib).(draw.Image), pd.SubImage(ib), &g.Options)
+		}
+		g.DrawAt(tmp, src, pt, op)
+		ditherImage(pd.SubImage(ib).(draw.Image), tmp.SubImage(ib), convertPalette(pd.Palette), g.Options.Dither, &g.Options)
+		return
+	}
+
 	switch op {
 	case OverOperator:
 		tb := g.Bounds(src.Bounds())
 		tb = tb.Sub(tb.Min).Add(pt)
-		tmp := createTempImage(tb)
+		tmp := createTempImageFor(tb, src, dst)
 		g.Draw(tmp, src)
 		pixGetterDst := newPixelGetter(dst)
 		pixGetterTmp := newPixelGetter(tmp)
@@ -185,7 +308,7 @@ func (g *GIFT) DrawAt(dst draw.Image, src image.Image, pt image.Point, op Operat
 		}
 		tb := g.Bounds(src.Bounds())
 		tb = tb.Sub(tb.Min).Add(pt)
-		tmp := createTempImage(tb)
+		tmp := createTempImageFor(tb, src, dst)
 		g.Draw(tmp, src)
 		pixGetter := newPixelGetter(tmp)
 		pixSetter := newPixelSetter(dst)
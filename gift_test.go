@@ -8,6 +8,8 @@ import (
 	"os"
 	"reflect"
 	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
 )
 
 type testFilter struct {
@@ -518,6 +520,66 @@ func TestDraw(t *testing.T) {
 	}
 }
 
+func TestApply(t *testing.T) {
+	r := image.Rect(-2, 3, 6, 9)
+	pal := color.Palette{color.NRGBA{0, 0, 0, 0xff}, color.NRGBA{0xff, 0xff, 0xff, 0xff}}
+	testData := []struct {
+		desc string
+		src  image.Image
+		want reflect.Type
+	}{
+		{"Gray", image.NewGray(r), reflect.TypeOf(&image.Gray{})},
+		{"Gray16", image.NewGray16(r), reflect.TypeOf(&image.Gray16{})},
+		{"Alpha", image.NewAlpha(r), reflect.TypeOf(&image.Alpha{})},
+		{"RGBA", image.NewRGBA(r), reflect.TypeOf(&image.RGBA{})},
+		{"RGBA64", image.NewRGBA64(r), reflect.TypeOf(&image.RGBA64{})},
+		{"NRGBA", image.NewNRGBA(r), reflect.TypeOf(&image.NRGBA{})},
+		{"NRGBA64", image.NewNRGBA64(r), reflect.TypeOf(&image.NRGBA64{})},
+		{"F32RGBA", giftimage.NewF32RGBA(r), reflect.TypeOf(&giftimage.F32RGBA{})},
+		{"YCbCr", image.NewYCbCr(r, image.YCbCrSubsampleRatio420), reflect.TypeOf(&image.YCbCr{})},
+		{"Paletted", image.NewPaletted(r, pal), reflect.TypeOf(&image.NRGBA{})},
+		{"NYCbCrA", image.NewNYCbCrA(r, image.YCbCrSubsampleRatio444), reflect.TypeOf(&image.NRGBA64{})},
+	}
+
+	for _, d := range testData {
+		g := New(Resize(4, 3, LinearResampling), FlipHorizontal())
+		dst := g.Apply(d.src)
+		if reflect.TypeOf(dst) != d.want {
+			t.Errorf("test [%s] failed: expected %v got %T", d.desc, d.want, dst)
+		}
+		if !dst.Bounds().Eq(image.Rect(0, 0, 4, 3)) {
+			t.Errorf("test [%s] failed: expected bounds (0,0)-(4,3) got %v", d.desc, dst.Bounds())
+		}
+	}
+
+	src := image.NewGray16(image.Rect(0, 0, 3, 1))
+	src.Pix = []uint8{0x01, 0x02, 0x80, 0x81, 0xfe, 0xff}
+	dst := New(FlipHorizontal()).Apply(src).(*image.Gray16)
+	if !comparePix(dst.Pix, []uint8{0xfe, 0xff, 0x80, 0x81, 0x01, 0x02}) {
+		t.Errorf("Apply Gray16: 16-bit precision lost: %v", dst.Pix)
+	}
+
+	psrc := image.NewPaletted(image.Rect(0, 0, 2, 1), pal)
+	psrc.Pix = []uint8{0, 1}
+	nrgba := New(Invert()).Apply(psrc).(*image.NRGBA)
+	if !comparePix(nrgba.Pix, []uint8{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0xff}) {
+		t.Errorf("Apply Paletted: unexpected result %v", nrgba.Pix)
+	}
+	pdst, ok := New(Invert()).ApplyModel(psrc, pal).(*image.Paletted)
+	if !ok || !comparePix(pdst.Pix, []uint8{1, 0}) {
+		t.Errorf("ApplyModel palette: unexpected result %#v", pdst)
+	}
+
+	gray := New().ApplyModel(image.NewRGBA(image.Rect(0, 0, 2, 2)), color.GrayModel)
+	if _, ok := gray.(*image.Gray); !ok {
+		t.Errorf("ApplyModel GrayModel: expected *image.Gray got %T", gray)
+	}
+	ycc, ok := New().ApplyModel(image.NewRGBA(image.Rect(0, 0, 2, 2)), color.YCbCrModel).(*image.YCbCr)
+	if !ok || ycc.SubsampleRatio != image.YCbCrSubsampleRatio444 {
+		t.Errorf("ApplyModel YCbCrModel: unexpected result %T", ycc)
+	}
+}
+
 func loadImage(t *testing.T, filename string) image.Image {
 	f, err := os.Open(filename)
 	if err != nil {
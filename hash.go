@@ -0,0 +1,97 @@
+package gift
+
+import (
+	"image"
+	"math/bits"
+	"sort"
+)
+
+// hashPixels returns the luminance of the image downscaled to w x h pixels.
+func hashPixels(img image.Image, w, h int) []float64 {
+	small := image.NewGray16(image.Rect(0, 0, w, h))
+	New(Resize(w, h, BoxResampling)).Draw(small, img)
+	vals := make([]float64, w*h)
+	for i := range vals {
+		vals[i] = float64(uint16(small.Pix[2*i])<<8 | uint16(small.Pix[2*i+1]))
+	}
+	return vals
+}
+
+// hashBits sets the bits of the hash, starting from the most significant bit,
+// for the values that are greater than the threshold.
+func hashBits(vals []float64, threshold float64) uint64 {
+	var h uint64
+	for _, v := range vals {
+		h <<= 1
+		if v > threshold {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// AverageHash calculates the average hash (aHash) of an image.
+// The image is reduced to 8x8 grayscale pixels and every bit of the hash tells
+// whether the corresponding pixel is brighter than the average.
+// Similar images have hashes with a small Hamming distance.
+func AverageHash(img image.Image) uint64 {
+	if img.Bounds().Empty() {
+		return 0
+	}
+	vals := hashPixels(img, 8, 8)
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	return hashBits(vals, sum/float64(len(vals)))
+}
+
+// DifferenceHash calculates the difference hash (dHash) of an image.
+// The image is reduced to 9x8 grayscale pixels and every bit of the hash tells
+// whether a pixel is brighter than its left neighbour.
+// Similar images have hashes with a small Hamming distance.
+func DifferenceHash(img image.Image) uint64 {
+	if img.Bounds().Empty() {
+		return 0
+	}
+	vals := hashPixels(img, 9, 8)
+	diffs := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			diffs = append(diffs, vals[y*9+x+1]-vals[y*9+x])
+		}
+	}
+	return hashBits(diffs, 0)
+}
+
+// PerceptualHash calculates the DCT-based perceptual hash (pHash) of an image.
+// The image is reduced to 32x32 grayscale pixels and transformed using the discrete cosine transform.
+// Every bit of the hash tells whether one of the 8x8 lowest frequency coefficients is greater than their median.
+// Similar images have hashes with a small Hamming distance.
+//
+// Example:
+//
+//	if gift.HammingDistance(gift.PerceptualHash(img1), gift.PerceptualHash(img2)) <= 10 {
+//		// the images are likely duplicates
+//	}
+//
+func PerceptualHash(img image.Image) uint64 {
+	if img.Bounds().Empty() {
+		return 0
+	}
+	const n = 32
+	coef := dct2d(hashPixels(img, n, n), n)
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		low = append(low, coef[y*n:y*n+8]...)
+	}
+	sorted := append([]float64(nil), low...)
+	sort.Float64s(sorted)
+	median := (sorted[31] + sorted[32]) / 2
+	return hashBits(low, median)
+}
+
+// HammingDistance returns the number of bits that differ between two hashes.
+func HammingDistance(hash1, hash2 uint64) int {
+	return bits.OnesCount64(hash1 ^ hash2)
+}
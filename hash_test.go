@@ -0,0 +1,95 @@
+package gift
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestDCT2D(t *testing.T) {
+	const n = 6
+	src := make([]float64, n*n)
+	for i := range src {
+		src[i] = float64((i*7)%11) - 3
+	}
+	got := dct2d(src, n)
+	for v := 0; v < n; v++ {
+		for u := 0; u < n; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				for x := 0; x < n; x++ {
+					sum += src[y*n+x] *
+						math.Cos(math.Pi*float64(2*x+1)*float64(u)/(2*n)) *
+						math.Cos(math.Pi*float64(2*y+1)*float64(v)/(2*n))
+				}
+			}
+			su, sv := math.Sqrt(2.0/n), math.Sqrt(2.0/n)
+			if u == 0 {
+				su = math.Sqrt(1.0 / n)
+			}
+			if v == 0 {
+				sv = math.Sqrt(1.0 / n)
+			}
+			if want := su * sv * sum; math.Abs(got[v*n+u]-want) > 1e-9 {
+				t.Errorf("dct2d (%d, %d): expected %v got %v", u, v, want, got[v*n+u])
+			}
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	testData := []struct {
+		h1, h2 uint64
+		d      int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff00, 0x0ff0, 8},
+		{0, math.MaxUint64, 64},
+	}
+	for _, d := range testData {
+		if got := HammingDistance(d.h1, d.h2); got != d.d {
+			t.Errorf("HammingDistance(%x, %x): expected %d got %d", d.h1, d.h2, d.d, got)
+		}
+	}
+}
+
+func TestPerceptualHashes(t *testing.T) {
+	src := loadImage(t, "testdata/src.png")
+	similar := []string{"resize", "gaussian_blur", "brightness_increse", "contrast_increse", "gamma_1.5"}
+	different := []string{"rotate_180", "invert"}
+
+	hashes := []struct {
+		name string
+		fn   func(image.Image) uint64
+		max  int
+	}{
+		{"AverageHash", AverageHash, 8},
+		{"DifferenceHash", DifferenceHash, 8},
+		{"PerceptualHash", PerceptualHash, 10},
+	}
+	for _, h := range hashes {
+		hsrc := h.fn(src)
+		if h.fn(src) != hsrc {
+			t.Errorf("%s: hash is not deterministic", h.name)
+		}
+		for _, name := range similar {
+			if d := HammingDistance(hsrc, h.fn(loadImage(t, "testdata/dst_"+name+".png"))); d > h.max {
+				t.Errorf("%s: %s: expected distance <= %d got %d", h.name, name, h.max, d)
+			}
+		}
+		for _, name := range different {
+			if d := HammingDistance(hsrc, h.fn(loadImage(t, "testdata/dst_"+name+".png"))); d <= 20 {
+				t.Errorf("%s: %s: expected distance > 20 got %d", h.name, name, d)
+			}
+		}
+		if v := h.fn(image.NewGray(image.Rect(0, 0, 0, 0))); v != 0 {
+			t.Errorf("%s: expected 0 for an empty image got %x", h.name, v)
+		}
+	}
+
+	flat := image.NewGray(image.Rect(0, 0, 20, 20))
+	if AverageHash(flat) != 0 || DifferenceHash(flat) != 0 {
+		t.Error("hashes of a flat image must be 0")
+	}
+}
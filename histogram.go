@@ -0,0 +1,260 @@
+package gift
+
+import (
+	"image"
+	"math"
+	"sync"
+)
+
+// Histogram channel indices.
+const (
+	histRed = iota
+	histGreen
+	histBlue
+	histAlpha
+	histLuminance
+)
+
+// imageHistograms holds the histograms and the moments of the red, green, blue and alpha channels
+// and the luminance of an image.
+type imageHistograms struct {
+	bins     int
+	total    int
+	ch       [5][]int
+	min, max [5]float32
+	sum      [5]float64
+	sumSq    [5]float64
+}
+
+// histogramBins returns the number of bins that represents every value of the image channels.
+func histogramBins(img image.Image) int {
+	switch img.(type) {
+	case *image.NRGBA, *image.RGBA, *image.Gray, *image.YCbCr, *image.Paletted:
+		return 0xff + 1
+	}
+	return 0xffff + 1
+}
+
+func histogramBin(v float32, bins int) int {
+	i := int(v*float32(bins-1) + 0.5)
+	if i < 0 {
+		return 0
+	}
+	if i >= bins {
+		return bins - 1
+	}
+	return i
+}
+
+// newImageHistograms creates empty histograms with the given number of bins.
+func newImageHistograms(bins int) *imageHistograms {
+	h := &imageHistograms{bins: bins}
+	for i := range h.ch {
+		h.ch[i] = make([]int, bins)
+		h.min[i] = float32(math.Inf(1))
+		h.max[i] = float32(math.Inf(-1))
+	}
+	return h
+}
+
+// merge adds the values counted by the other histograms to h.
+func (h *imageHistograms) merge(other *imageHistograms) {
+	h.total += other.total
+	for i := range h.ch {
+		for j, n := range other.ch[i] {
+			h.ch[i][j] += n
+		}
+		h.min[i] = minf32(h.min[i], other.min[i])
+		h.max[i] = maxf32(h.max[i], other.max[i])
+		h.sum[i] += other.sum[i]
+		h.sumSq[i] += other.sumSq[i]
+	}
+}
+
+// computeHistograms calculates the histograms of the image channels using the given number of bins.
+func computeHistograms(img image.Image, bins int, options *Options) *imageHistograms {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := img.Bounds()
+	h := newImageHistograms(bins)
+	if srcb.Empty() {
+		return h
+	}
+
+	// The parallel chunks reuse the partial histograms of the finished chunks, so there are
+	// no more partial histograms than workers, and they are merged once at the end.
+	var mu sync.Mutex
+	var parts, free []*imageHistograms
+	acquire := func() *imageHistograms {
+		mu.Lock()
+		defer mu.Unlock()
+		if n := len(free); n > 0 {
+			part := free[n-1]
+			free = free[:n-1]
+			return part
+		}
+		part := newImageHistograms(bins)
+		parts = append(parts, part)
+		return part
+	}
+	release := func(part *imageHistograms) {
+		mu.Lock()
+		free = append(free, part)
+		mu.Unlock()
+	}
+
+	pixGetter := newPixelGetter(img)
+	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+		part := acquire()
+		for y := pmin; y < pmax; y++ {
+			for x := srcb.Min.X; x < srcb.Max.X; x++ {
+				px := pixGetter.getPixel(x, y)
+				vals := [5]float32{px.R, px.G, px.B, px.A, 0.299*px.R + 0.587*px.G + 0.114*px.B}
+				for i, v := range vals {
+					part.ch[i][histogramBin(v, bins)]++
+					part.min[i] = minf32(part.min[i], v)
+					part.max[i] = maxf32(part.max[i], v)
+					part.sum[i] += float64(v)
+					part.sumSq[i] += float64(v) * float64(v)
+				}
+			}
+		}
+		part.total += (pmax - pmin) * srcb.Dx()
+		release(part)
+	})
+
+	for _, part := range parts {
+		h.merge(part)
+	}
+	return h
+}
+
+// clipRange returns the lowest and the highest values of the histogram ignoring
+// the clip fraction of the values at both ends.
+func clipRange(hist []int, total int, clip float32) (lo, hi float32) {
+	bins := len(hist)
+	limit := int(clip * float32(total))
+	ilo, ihi := 0, bins-1
+	for sum := 0; ilo < bins-1; ilo++ {
+		sum += hist[ilo]
+		if sum > limit {
+			break
+		}
+	}
+	for sum := 0; ihi > 0; ihi-- {
+		sum += hist[ihi]
+		if sum > limit {
+			break
+		}
+	}
+	if ihi < ilo {
+		ihi = ilo
+	}
+	q := 1 / float32(bins-1)
+	return float32(ilo) * q, float32(ihi) * q
+}
+
+// Histograms holds the histograms of the red, green, blue and alpha channels and the luminance of an image.
+// The bin i of a histogram counts the pixels with the channel values closest to i / (bins - 1).
+type Histograms struct {
+	Red, Green, Blue, Alpha, Luminance []int
+}
+
+// Histogram calculates the histograms of the image channels using the given number of bins.
+// If bins is less than 2, 256 bins are used. The luminance is calculated as 0.299*R + 0.587*G + 0.114*B.
+//
+// Example:
+//
+//	h := gift.Histogram(src, 256)
+//	fmt.Println(h.Luminance[0], "pixels are black")
+//
+func Histogram(img image.Image, bins int) Histograms {
+	if bins < 2 {
+		bins = 0xff + 1
+	}
+	h := computeHistograms(img, bins, nil)
+	return Histograms{
+		Red:       h.ch[histRed],
+		Green:     h.ch[histGreen],
+		Blue:      h.ch[histBlue],
+		Alpha:     h.ch[histAlpha],
+		Luminance: h.ch[histLuminance],
+	}
+}
+
+// ChannelStats holds the statistics of the values of an image channel. The values are in range [0, 1],
+// except for the floating point images that may hold any values.
+type ChannelStats struct {
+	Min, Max, Mean, StdDev float32
+
+	hist  []int
+	total int
+}
+
+// Percentile returns the smallest value v of the channel such that at least p percent
+// of the values are less than or equal to v. The percentiles are calculated from the histogram of
+// the channel with 256 bins for 8-bit images and 65536 bins for other images.
+func (s ChannelStats) Percentile(p float32) float32 {
+	if s.total == 0 || len(s.hist) < 2 {
+		return 0
+	}
+	limit := float64(minf32(maxf32(p, 0), 100)) / 100 * float64(s.total)
+	sum := 0
+	for i, n := range s.hist {
+		sum += n
+		if n > 0 && float64(sum) >= limit {
+			return float32(i) / float32(len(s.hist)-1)
+		}
+	}
+	return 1
+}
+
+// Median returns the median value of the channel.
+func (s ChannelStats) Median() float32 {
+	return s.Percentile(50)
+}
+
+// ImageStats holds the statistics of the red, green, blue and alpha channels and the luminance of an image.
+type ImageStats struct {
+	Red, Green, Blue, Alpha, Luminance ChannelStats
+	// Pixels is the number of pixels of the image.
+	Pixels int
+}
+
+// Stats calculates the statistics of the image channels.
+// The luminance is calculated as 0.299*R + 0.587*G + 0.114*B.
+//
+// Example:
+//
+//	s := gift.Stats(src)
+//	if s.Luminance.Mean < 0.3 {
+//		g.Add(gift.Gamma(1.5)) // lighten dark images
+//	}
+//
+func Stats(img image.Image) ImageStats {
+	h := computeHistograms(img, histogramBins(img), nil)
+	var cs [5]ChannelStats
+	for i := range cs {
+		cs[i] = ChannelStats{hist: h.ch[i], total: h.total}
+		if h.total == 0 {
+			continue
+		}
+		n := float64(h.total)
+		mean := h.sum[i] / n
+		variance := h.sumSq[i]/n - mean*mean
+		cs[i].Min = h.min[i]
+		cs[i].Max = h.max[i]
+		cs[i].Mean = float32(mean)
+		cs[i].StdDev = float32(math.Sqrt(math.Max(variance, 0)))
+	}
+	return ImageStats{
+		Red:       cs[histRed],
+		Green:     cs[histGreen],
+		Blue:      cs[histBlue],
+		Alpha:     cs[histAlpha],
+		Luminance: cs[histLuminance],
+		Pixels:    h.total,
+	}
+}
@@ -0,0 +1,150 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+func TestComputeHistograms(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(-1, -1, 3, 1))
+	colors := []color.NRGBA{
+		{0x00, 0x00, 0x00, 0xff},
+		{0xff, 0x00, 0x00, 0xff},
+		{0xff, 0xff, 0xff, 0x80},
+		{0x10, 0x20, 0x30, 0x00},
+	}
+	for i, c := range colors {
+		src.SetNRGBA(-1+i, -1, c)
+		src.SetNRGBA(-1+i, 0, c)
+	}
+	if n := histogramBins(src); n != 256 {
+		t.Errorf("histogramBins: expected 256 got %d", n)
+	}
+	if n := histogramBins(image.NewGray16(image.Rect(0, 0, 1, 1))); n != 65536 {
+		t.Errorf("histogramBins: expected 65536 got %d", n)
+	}
+
+	for _, prlz := range []bool{true, false} {
+		h := computeHistograms(src, 256, &Options{Parallelization: prlz})
+		if h.total != 8 {
+			t.Errorf("computeHistograms: expected total 8 got %d", h.total)
+		}
+		checks := []struct {
+			ch, bin, count int
+		}{
+			{histRed, 0x00, 2},
+			{histRed, 0xff, 4},
+			{histRed, 0x10, 2},
+			{histGreen, 0x00, 4},
+			{histBlue, 0x30, 2},
+			{histAlpha, 0xff, 4},
+			{histAlpha, 0x80, 2},
+			{histAlpha, 0x00, 2},
+			{histLuminance, 0x00, 2},
+			{histLuminance, 0x4c, 2},
+			{histLuminance, 0xff, 2},
+		}
+		for _, c := range checks {
+			if got := h.ch[c.ch][c.bin]; got != c.count {
+				t.Errorf("computeHistograms: channel %d bin %#x: expected %d got %d", c.ch, c.bin, c.count, got)
+			}
+		}
+	}
+}
+
+func TestClipRange(t *testing.T) {
+	hist := []int{0, 2, 3, 0, 0, 5, 0, 0, 0, 0, 0}
+	testData := []struct {
+		clip   float32
+		lo, hi float32
+	}{
+		{0, 0.1, 0.5},
+		{0.2, 0.2, 0.5},
+		{0.5, 0.5, 0.5},
+		{1, 1, 1},
+	}
+	for _, d := range testData {
+		lo, hi := clipRange(hist, 10, d.clip)
+		if absf32(lo-d.lo) > 1e-6 || absf32(hi-d.hi) > 1e-6 {
+			t.Errorf("clipRange %v: expected %v, %v got %v, %v", d.clip, d.lo, d.hi, lo, hi)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 5, 1))
+	copy(src.Pix, []uint8{0x00, 0x20, 0x80, 0xd0, 0xff})
+	h := Histogram(src, 3)
+	want := []int{2, 1, 2}
+	for _, ch := range [][]int{h.Red, h.Green, h.Blue, h.Luminance} {
+		if !reflect.DeepEqual(ch, want) {
+			t.Errorf("Histogram: expected %v got %v", want, ch)
+		}
+	}
+	if !reflect.DeepEqual(h.Alpha, []int{0, 0, 5}) {
+		t.Errorf("Histogram: expected alpha %v got %v", []int{0, 0, 5}, h.Alpha)
+	}
+	if h := Histogram(src, 0); len(h.Red) != 256 || h.Red[0x20] != 1 {
+		t.Errorf("Histogram: expected 256 bins by default got %d", len(h.Red))
+	}
+}
+
+func TestStats(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	copy(src.Pix, []uint8{
+		0x00, 0xff, 0x33, 0xff,
+		0x33, 0xff, 0x33, 0xff,
+		0x66, 0xff, 0x33, 0x00,
+		0xff, 0xff, 0x33, 0xff,
+	})
+	s := Stats(src)
+	if s.Pixels != 4 {
+		t.Errorf("Stats: expected 4 pixels got %d", s.Pixels)
+	}
+	checks := []struct {
+		desc string
+		got  float32
+		want float32
+	}{
+		{"red min", s.Red.Min, 0},
+		{"red max", s.Red.Max, 1},
+		{"red mean", s.Red.Mean, 0.4},
+		{"red stddev", s.Red.StdDev, 0.3741657},
+		{"red median", s.Red.Median(), 0.2},
+		{"red percentile 0", s.Red.Percentile(0), 0},
+		{"red percentile 75", s.Red.Percentile(75), 0.4},
+		{"red percentile 100", s.Red.Percentile(100), 1},
+		{"green mean", s.Green.Mean, 1},
+		{"green stddev", s.Green.StdDev, 0},
+		{"blue median", s.Blue.Median(), 0.2},
+		{"alpha mean", s.Alpha.Mean, 0.75},
+		{"alpha min", s.Alpha.Min, 0},
+	}
+	for _, c := range checks {
+		if absf32(c.got-c.want) > 1e-5 {
+			t.Errorf("Stats %s: expected %v got %v", c.desc, c.want, c.got)
+		}
+	}
+
+	// HDR values, all above 1
+	hdr := giftimage.NewF32RGBA(image.Rect(0, 0, 200, 100))
+	for i := range hdr.Pix {
+		hdr.Pix[i] = 2 + float32(i%7)/2
+	}
+	s = Stats(hdr)
+	if s.Pixels != 200*100 {
+		t.Errorf("Stats of HDR image: expected %d pixels got %d", 200*100, s.Pixels)
+	}
+	if s.Red.Min != 2 || s.Red.Max != 5 || s.Alpha.Min != 2 || s.Luminance.Min < 2 {
+		t.Errorf("Stats of HDR image: unexpected min/max %+v %+v", s.Red, s.Luminance)
+	}
+
+	empty := Stats(image.NewGray(image.Rect(0, 0, 0, 0)))
+	if empty.Pixels != 0 || empty.Luminance.Max != 0 || empty.Luminance.Median() != 0 {
+		t.Errorf("Stats: unexpected stats of an empty image %+v", empty.Luminance)
+	}
+}
@@ -0,0 +1,122 @@
+package gift
+
+// HueRange is a range of hues adjusted by the SelectiveHSL filter.
+type HueRange int
+
+// Hue ranges. Every range is centered at the hue of its color,
+// the adjustments blend smoothly between the neighboring ranges.
+const (
+	RedHues     HueRange = iota // 0°
+	OrangeHues                  // 30°
+	YellowHues                  // 60°
+	GreenHues                   // 120°
+	AquaHues                    // 180°
+	BlueHues                    // 240°
+	PurpleHues                  // 270°
+	MagentaHues                 // 300°
+)
+
+// hueRangeCenters are the centers of the hue ranges in turns (the hue in range [0, 1)).
+var hueRangeCenters = [...]float32{0, 30.0 / 360, 60.0 / 360, 120.0 / 360, 180.0 / 360, 240.0 / 360, 270.0 / 360, 300.0 / 360}
+
+// HSLAdjustment specifies the adjustment of a hue range.
+// Hue is the hue shift in degrees, typically in range (-30, 30).
+// Saturation and Lightness are in range (-100, 100), the zero values leave the colors unchanged.
+type HSLAdjustment struct {
+	Hue, Saturation, Lightness float32
+}
+
+// hueRangeWeights returns the two hue ranges surrounding the hue and the weight of the second range.
+func hueRangeWeights(h float32) (r0, r1 HueRange, w1 float32) {
+	n := len(hueRangeCenters)
+	for i := 0; i < n; i++ {
+		c0 := hueRangeCenters[i]
+		c1 := float32(1)
+		if i+1 < n {
+			c1 = hueRangeCenters[i+1]
+		}
+		if h >= c0 && h < c1 {
+			t := (h - c0) / (c1 - c0)
+			return HueRange(i), HueRange((i + 1) % n), t * t * (3 - 2*t)
+		}
+	}
+	return RedHues, RedHues, 0
+}
+
+// SelectiveHSL creates a filter that adjusts the hue, saturation and lightness of the colors
+// in the given hue ranges separately, like the HSL panel of photo editors.
+// The adjustments blend smoothly between the neighboring ranges and fade out
+// for the less saturated colors, so the grays are not changed.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.SelectiveHSL(map[gift.HueRange]gift.HSLAdjustment{
+//			gift.BlueHues:   {Saturation: 30, Lightness: -20}, // deeper sky
+//			gift.OrangeHues: {Hue: -5},                        // warmer skin tones
+//		}),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func SelectiveHSL(adjustments map[HueRange]HSLAdjustment) Filter {
+	var adj [len(hueRangeCenters)]HSLAdjustment
+	changed := false
+	for r, a := range adjustments {
+		if r < RedHues || r > MagentaHues {
+			continue
+		}
+		adj[r] = HSLAdjustment{
+			Hue:        a.Hue / 360,
+			Saturation: minf32(maxf32(a.Saturation, -100), 100) / 100,
+			Lightness:  minf32(maxf32(a.Lightness, -100), 100) / 100,
+		}
+		changed = changed || adj[r] != HSLAdjustment{}
+	}
+	if !changed {
+		return &copyimageFilter{}
+	}
+
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			h, s, l := convertRGBToHSL(px.R, px.G, px.B)
+			if s == 0 {
+				return px
+			}
+			r0, r1, w1 := hueRangeWeights(h)
+			w0 := 1 - w1
+			dh := adj[r0].Hue*w0 + adj[r1].Hue*w1
+			ds := adj[r0].Saturation*w0 + adj[r1].Saturation*w1
+			dl := (adj[r0].Lightness*w0 + adj[r1].Lightness*w1) * s
+
+			h = normalizeHue(h + dh*s)
+			s = minf32(s*(1+ds), 1)
+			if dl > 0 {
+				l += (1 - l) * dl
+			} else {
+				l += l * dl
+			}
+			r, g, b := convertHSLToRGB(h, s, l)
+			return pixel{r, g, b, px.A}
+		},
+	}
+}
+
+// Vibrance creates a filter that changes the saturation of an image, affecting the less saturated colors
+// more than the saturated ones. It boosts the muted colors while avoiding the oversaturation.
+// The percentage parameter must be in range (-100, 100). The percentage = 0 gives the original image.
+func Vibrance(percentage float32) Filter {
+	p := minf32(maxf32(percentage, -100), 100) / 100
+	if p == 0 {
+		return &copyimageFilter{}
+	}
+
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			h, s, l := convertRGBToHSL(px.R, px.G, px.B)
+			s = minf32(s*(1+p*(1-s)), 1)
+			r, g, b := convertHSLToRGB(h, s, l)
+			return pixel{r, g, b, px.A}
+		},
+	}
+}
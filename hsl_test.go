@@ -0,0 +1,90 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestHueRangeWeights(t *testing.T) {
+	testData := []struct {
+		hue    float32
+		r0, r1 HueRange
+		w1     float32
+	}{
+		{0, RedHues, OrangeHues, 0},
+		{15.0 / 360, RedHues, OrangeHues, 0.5},
+		{90.0 / 360, YellowHues, GreenHues, 0.5},
+		{240.0 / 360, BlueHues, PurpleHues, 0},
+		{330.0 / 360, MagentaHues, RedHues, 0.5},
+	}
+	for _, d := range testData {
+		r0, r1, w1 := hueRangeWeights(d.hue)
+		if r0 != d.r0 || r1 != d.r1 || absf32(w1-d.w1) > 1e-5 {
+			t.Errorf("hue %v: expected (%v, %v, %v) got (%v, %v, %v)", d.hue*360, d.r0, d.r1, d.w1, r0, r1, w1)
+		}
+	}
+}
+
+func TestSelectiveHSL(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	src.Pix = []uint8{
+		0xff, 0x00, 0x00, 0xff, // red
+		0x00, 0x00, 0xff, 0xff, // blue
+		0x80, 0x80, 0x80, 0xff, // gray
+		0x00, 0xff, 0xff, 0x80, // aqua
+	}
+
+	f := SelectiveHSL(map[HueRange]HSLAdjustment{
+		BlueHues: {Lightness: -50},
+		AquaHues: {Hue: 60, Saturation: -50},
+	})
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+
+	want := []uint8{
+		0xff, 0x00, 0x00, 0xff,
+		0x00, 0x00, 0x80, 0xff,
+		0x80, 0x80, 0x80, 0xff,
+		0x40, 0x40, 0xbf, 0x80,
+	}
+	for i := range want {
+		if absf32(float32(dst.Pix[i])-float32(want[i])) > 1 {
+			t.Errorf("SelectiveHSL failed: expected %#v got %#v", want, dst.Pix)
+			break
+		}
+	}
+
+	// no adjustments
+	f = SelectiveHSL(map[HueRange]HSLAdjustment{GreenHues: {}})
+	f.Draw(dst, src, nil)
+	if !comparePix(dst.Pix, src.Pix) {
+		t.Errorf("SelectiveHSL without adjustments changed the image: %#v", dst.Pix)
+	}
+}
+
+func TestVibrance(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Pix = []uint8{
+		0x99, 0x66, 0x66, 0xff, // muted red, saturation 0.2
+		0xff, 0x00, 0x00, 0xff, // saturated red
+	}
+	f := Vibrance(100)
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+
+	// saturation 0.2 * (1 + 0.8) = 0.36
+	if c := dst.NRGBAAt(0, 0); !compareColorsNRGBA(c, color.NRGBA{0xae, 0x51, 0x51, 0xff}, 1) {
+		t.Errorf("muted color: expected %v got %v", color.NRGBA{0xae, 0x51, 0x51, 0xff}, c)
+	}
+	if c := dst.NRGBAAt(1, 0); c != (color.NRGBA{0xff, 0x00, 0x00, 0xff}) {
+		t.Errorf("saturated color: expected unchanged got %v", c)
+	}
+
+	f = Vibrance(-100)
+	f.Draw(dst, src, nil)
+	// saturation 0.2 * 0.2 = 0.04
+	if c := dst.NRGBAAt(0, 0); !compareColorsNRGBA(c, color.NRGBA{0x85, 0x7a, 0x7a, 0xff}, 1) {
+		t.Errorf("muted color: expected %v got %v", color.NRGBA{0x85, 0x7a, 0x7a, 0xff}, c)
+	}
+}
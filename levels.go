@@ -0,0 +1,129 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+)
+
+// levels returns the levels adjustment function.
+func levels(inBlack, inWhite, gamma, outBlack, outWhite float32) func(float32) float32 {
+	e := 1 / maxf32(gamma, 1.0e-5)
+	d := inWhite - inBlack
+	return func(x float32) float32 {
+		var v float32
+		switch {
+		case d > 0:
+			v = minf32(maxf32((x-inBlack)/d, 0), 1)
+		case x >= inWhite:
+			v = 1
+		}
+		if e != 1 {
+			v = powf32(v, e)
+		}
+		return outBlack + v*(outWhite-outBlack)
+	}
+}
+
+// Levels creates a filter that adjusts the tonal range of the color channels of an image.
+// The input values between inBlack and inWhite are stretched to the range between outBlack and outWhite,
+// the values outside of the input range are clipped. The gamma parameter adjusts the midtones:
+// gamma = 1 is linear, gamma greater than 1 lightens the midtones and gamma less than 1 darkens them.
+// All the parameters except gamma are in range [0, 1].
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Levels(0.1, 0.9, 1.2, 0, 1),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Levels(inBlack, inWhite, gamma, outBlack, outWhite float32) Filter {
+	fn := levels(inBlack, inWhite, gamma, outBlack, outWhite)
+	return &channelsFilter{
+		fns: [4]func(float32) float32{fn, fn, fn, nil},
+	}
+}
+
+// ChannelLevels creates a filter that adjusts the tonal range of a single channel of an image.
+// The parameters are the same as in the Levels filter.
+func ChannelLevels(channel Channel, inBlack, inWhite, gamma, outBlack, outWhite float32) Filter {
+	p := &channelsFilter{}
+	if channel >= RedChannel && channel <= AlphaChannel {
+		p.fns[channel] = levels(inBlack, inWhite, gamma, outBlack, outWhite)
+	}
+	return p
+}
+
+// defaultAutoClipPercent is the percentage of the darkest and the brightest values ignored by AutoContrast.
+const defaultAutoClipPercent = 0.1
+
+type autoLevelsFilter struct {
+	clip       float32
+	perChannel bool
+}
+
+func (p *autoLevelsFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *autoLevelsFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	h := computeHistograms(src, histogramBins(src), options)
+	var f channelsFilter
+	if p.perChannel {
+		for ch := histRed; ch <= histBlue; ch++ {
+			lo, hi := clipRange(h.ch[ch], h.total, p.clip)
+			if lo < hi {
+				f.fns[ch] = levels(lo, hi, 1, 0, 1)
+			}
+		}
+	} else {
+		combined := make([]int, h.bins)
+		for ch := histRed; ch <= histBlue; ch++ {
+			for i, n := range h.ch[ch] {
+				combined[i] += n
+			}
+		}
+		lo, hi := clipRange(combined, h.total*3, p.clip)
+		if lo < hi {
+			fn := levels(lo, hi, 1, 0, 1)
+			f.fns = [4]func(float32) float32{fn, fn, fn, nil}
+		}
+	}
+	f.Draw(dst, src, options)
+}
+
+// AutoLevels creates a filter that stretches the range of every color channel of an image
+// independently, so that the darkest values become black and the brightest values become white.
+// The clipPercent parameter is the percentage of the darkest and the brightest values ignored
+// at each end of the channel histograms, typically in range [0, 1].
+// As the channels are adjusted independently, AutoLevels may also remove color casts.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.AutoLevels(0.5),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func AutoLevels(clipPercent float32) Filter {
+	return &autoLevelsFilter{
+		clip:       minf32(maxf32(clipPercent, 0), 50) / 100,
+		perChannel: true,
+	}
+}
+
+// AutoContrast creates a filter that stretches the range of all the color channels of an image together,
+// increasing the contrast without changing the hues. The darkest and the brightest 0.1% of the values are ignored.
+func AutoContrast() Filter {
+	return &autoLevelsFilter{
+		clip:       defaultAutoClipPercent / 100,
+		perChannel: false,
+	}
+}
@@ -0,0 +1,100 @@
+package gift
+
+import (
+	"image"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	testData := []struct {
+		desc           string
+		filter         Filter
+		srcPix, dstPix []uint8
+	}{
+		{
+			"levels identity",
+			Levels(0, 1, 1, 0, 1),
+			[]uint8{0x00, 0x40, 0x80, 0xff},
+			[]uint8{0x00, 0x40, 0x80, 0xff},
+		},
+		{
+			"levels input range",
+			Levels(0.25, 0.75, 1, 0, 1),
+			[]uint8{0x00, 0x40, 0x80, 0xc0, 0xff},
+			[]uint8{0x00, 0x01, 0x81, 0xff, 0xff},
+		},
+		{
+			"levels output range",
+			Levels(0, 1, 1, 0.2, 0.6),
+			[]uint8{0x00, 0x80, 0xff},
+			[]uint8{0x33, 0x66, 0x99},
+		},
+		{
+			"levels gamma",
+			Levels(0, 1, 2, 0, 1),
+			[]uint8{0x00, 0x40, 0xff},
+			[]uint8{0x00, 0x80, 0xff},
+		},
+		{
+			"levels empty input range",
+			Levels(0.5, 0.5, 1, 0, 1),
+			[]uint8{0x00, 0x7f, 0x80, 0xff},
+			[]uint8{0x00, 0x00, 0xff, 0xff},
+		},
+		{
+			"auto levels",
+			AutoLevels(0),
+			[]uint8{0x40, 0x60, 0x80, 0xc0},
+			[]uint8{0x00, 0x40, 0x7f, 0xff},
+		},
+		{
+			"auto levels clip",
+			AutoLevels(25),
+			[]uint8{0x00, 0x40, 0x60, 0x80, 0x80, 0xa0, 0xc0, 0xff},
+			[]uint8{0x00, 0x00, 0x00, 0x80, 0x80, 0xff, 0xff, 0xff},
+		},
+		{
+			"auto contrast",
+			AutoContrast(),
+			[]uint8{0x40, 0x60, 0x80, 0xc0},
+			[]uint8{0x00, 0x40, 0x7f, 0xff},
+		},
+		{
+			"auto contrast flat",
+			AutoContrast(),
+			[]uint8{0x40, 0x40},
+			[]uint8{0x40, 0x40},
+		},
+	}
+
+	for _, d := range testData {
+		src := image.NewGray(image.Rect(0, 0, len(d.srcPix), 1))
+		copy(src.Pix, d.srcPix)
+		dst := image.NewGray(src.Bounds())
+		New(d.filter).Draw(dst, src)
+		if !comparePix(dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v, %#v", d.desc, dst.Pix, d.dstPix)
+		}
+	}
+
+	// per channel adjustments
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	copy(src.Pix, []uint8{0x40, 0x20, 0x00, 0x80, 0xc0, 0x60, 0x80, 0xff})
+	testData2 := []struct {
+		desc   string
+		filter Filter
+		dstPix []uint8
+	}{
+		{"channel levels", ChannelLevels(GreenChannel, 0, 0.5, 1, 0, 1), []uint8{0x40, 0x40, 0x00, 0x80, 0xc0, 0xc0, 0x80, 0xff}},
+		{"channel levels alpha", ChannelLevels(AlphaChannel, 0, 1, 1, 1, 1), []uint8{0x40, 0x20, 0x00, 0xff, 0xc0, 0x60, 0x80, 0xff}},
+		{"auto levels", AutoLevels(0), []uint8{0x00, 0x00, 0x00, 0x80, 0xff, 0xff, 0xff, 0xff}},
+		{"auto contrast", AutoContrast(), []uint8{0x55, 0x2a, 0x00, 0x80, 0xff, 0x80, 0xaa, 0xff}},
+	}
+	for _, d := range testData2 {
+		dst := image.NewNRGBA(src.Bounds())
+		New(d.filter).Draw(dst, src)
+		if !comparePix(dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v, %#v", d.desc, dst.Pix, d.dstPix)
+		}
+	}
+}
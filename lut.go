@@ -0,0 +1,367 @@
+package gift
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+// LUTInterpolation is an interpolation algorithm used to look up the colors in a 3D LUT.
+type LUTInterpolation int
+
+// LUT interpolation algorithms.
+const (
+	// TrilinearInterpolation interpolates between the 8 nearest entries of the table.
+	TrilinearInterpolation LUTInterpolation = iota
+	// TetrahedralInterpolation interpolates between the 4 entries of the tetrahedron containing the color.
+	// It is faster than the trilinear interpolation and preserves the neutral colors.
+	TetrahedralInterpolation
+)
+
+// ColorLUT is a color lookup table. It consists of an optional 1D table (Shaper)
+// applied to every channel separately, followed by an optional 3D table (Cube).
+//
+// The Cube table holds Size*Size*Size colors, the red index changes fastest, then green, then blue:
+// the entry for the indices (r, g, b) is Cube[r + g*Size + b*Size*Size].
+// The input colors are mapped from the [ShaperMin, ShaperMax] and [CubeMin, CubeMax] domains to the tables.
+type ColorLUT struct {
+	Title string
+
+	Shaper               [][3]float32
+	ShaperMin, ShaperMax [3]float32
+
+	Size             int
+	Cube             [][3]float32
+	CubeMin, CubeMax [3]float32
+}
+
+// lookup1D applies the 1D table to the color using the linear interpolation.
+func (lut *ColorLUT) lookup1D(c [3]float32) [3]float32 {
+	n := len(lut.Shaper)
+	for i := 0; i < 3; i++ {
+		v := domainPos(c[i], lut.ShaperMin[i], lut.ShaperMax[i], n)
+		i0 := int(v)
+		i1 := minint(i0+1, n-1)
+		f := v - float32(i0)
+		c[i] = lut.Shaper[i0][i] + (lut.Shaper[i1][i]-lut.Shaper[i0][i])*f
+	}
+	return c
+}
+
+// lookup3D applies the 3D table to the color.
+func (lut *ColorLUT) lookup3D(c [3]float32, interpolation LUTInterpolation) (res [3]float32) {
+	n := lut.Size
+	r := domainPos(c[0], lut.CubeMin[0], lut.CubeMax[0], n)
+	g := domainPos(c[1], lut.CubeMin[1], lut.CubeMax[1], n)
+	b := domainPos(c[2], lut.CubeMin[2], lut.CubeMax[2], n)
+	r0, g0, b0 := int(r), int(g), int(b)
+	fr, fg, fb := r-float32(r0), g-float32(g0), b-float32(b0)
+	dr, dg, db := 1, n, n*n
+	if r0 == n-1 {
+		dr = 0
+	}
+	if g0 == n-1 {
+		dg = 0
+	}
+	if b0 == n-1 {
+		db = 0
+	}
+	i := r0 + g0*n + b0*n*n
+	c000 := lut.Cube[i]
+	c111 := lut.Cube[i+dr+dg+db]
+
+	if interpolation == TetrahedralInterpolation {
+		var w0, w1, w2, w3 float32
+		var c1, c2 [3]float32
+		switch {
+		case fr > fg && fg > fb:
+			w0, w1, w2, w3 = 1-fr, fr-fg, fg-fb, fb
+			c1, c2 = lut.Cube[i+dr], lut.Cube[i+dr+dg]
+		case fr > fg && fr > fb:
+			w0, w1, w2, w3 = 1-fr, fr-fb, fb-fg, fg
+			c1, c2 = lut.Cube[i+dr], lut.Cube[i+dr+db]
+		case fr > fg:
+			w0, w1, w2, w3 = 1-fb, fb-fr, fr-fg, fg
+			c1, c2 = lut.Cube[i+db], lut.Cube[i+dr+db]
+		case fb > fg:
+			w0, w1, w2, w3 = 1-fb, fb-fg, fg-fr, fr
+			c1, c2 = lut.Cube[i+db], lut.Cube[i+dg+db]
+		case fb > fr:
+			w0, w1, w2, w3 = 1-fg, fg-fb, fb-fr, fr
+			c1, c2 = lut.Cube[i+dg], lut.Cube[i+dg+db]
+		default:
+			w0, w1, w2, w3 = 1-fg, fg-fr, fr-fb, fb
+			c1, c2 = lut.Cube[i+dg], lut.Cube[i+dr+dg]
+		}
+		for k := 0; k < 3; k++ {
+			res[k] = w0*c000[k] + w1*c1[k] + w2*c2[k] + w3*c111[k]
+		}
+		return res
+	}
+
+	c100 := lut.Cube[i+dr]
+	c010 := lut.Cube[i+dg]
+	c110 := lut.Cube[i+dr+dg]
+	c001 := lut.Cube[i+db]
+	c101 := lut.Cube[i+dr+db]
+	c011 := lut.Cube[i+dg+db]
+	for k := 0; k < 3; k++ {
+		v00 := c000[k] + (c100[k]-c000[k])*fr
+		v10 := c010[k] + (c110[k]-c010[k])*fr
+		v01 := c001[k] + (c101[k]-c001[k])*fr
+		v11 := c011[k] + (c111[k]-c011[k])*fr
+		v0 := v00 + (v10-v00)*fg
+		v1 := v01 + (v11-v01)*fg
+		res[k] = v0 + (v1-v0)*fb
+	}
+	return res
+}
+
+// domainPos maps the value from the domain to the position in a table of the given size.
+func domainPos(v, min, max float32, size int) float32 {
+	if max > min {
+		v = (v - min) / (max - min)
+	}
+	return minf32(maxf32(v, 0), 1) * float32(size-1)
+}
+
+// LUT3D creates a filter that maps the colors of an image using a color lookup table.
+// The 1D table of the lut is applied first (if present), then the 3D table (if present).
+//
+// Supported interpolation algorithms: TrilinearInterpolation, TetrahedralInterpolation.
+//
+// Example:
+//
+//	f, err := os.Open("look.cube")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	lut, err := gift.ReadCubeLUT(f)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	g := gift.New(
+//		gift.LUT3D(lut, gift.TetrahedralInterpolation),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func LUT3D(lut *ColorLUT, interpolation LUTInterpolation) Filter {
+	has1D := len(lut.Shaper) > 0
+	has3D := lut.Size > 0 && len(lut.Cube) >= lut.Size*lut.Size*lut.Size
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			c := [3]float32{px.R, px.G, px.B}
+			if has1D {
+				c = lut.lookup1D(c)
+			}
+			if has3D {
+				c = lut.lookup3D(c, interpolation)
+			}
+			return pixel{c[0], c[1], c[2], px.A}
+		},
+	}
+}
+
+// BakeLUT3D creates a 3D lookup table of the given size (typically 17, 33 or 65)
+// with the result of applying the filters to every color of the table.
+// Only the filters that change each color independently of the other pixels (for example, Hue,
+// Saturation, Curves or ColorBalance) can be represented by a lookup table.
+//
+// Example:
+//
+//	lut := gift.BakeLUT3D(33,
+//		gift.Saturation(30),
+//		gift.Curves([]gift.CurvePoint{{0, 0}, {0.25, 0.2}, {0.75, 0.8}, {1, 1}}),
+//	)
+//	lut.Title = "Look"
+//	err := lut.WriteCube(w)
+//
+func BakeLUT3D(size int, filters ...Filter) *ColorLUT {
+	size = maxint(size, 2)
+	lut := &ColorLUT{
+		Size:    size,
+		Cube:    make([][3]float32, size*size*size),
+		CubeMax: [3]float32{1, 1, 1},
+	}
+
+	// every row holds size*size colors with the same blue value
+	src := giftimage.NewF32RGBA(image.Rect(0, 0, size*size, size))
+	for i := range lut.Cube {
+		j := i * 4
+		src.Pix[j+0] = float32(i%size) / float32(size-1)
+		src.Pix[j+1] = float32(i/size%size) / float32(size-1)
+		src.Pix[j+2] = float32(i/(size*size)) / float32(size-1)
+		src.Pix[j+3] = 1
+	}
+	g := New(filters...)
+	dst := giftimage.NewF32RGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+
+	pixGetter := newPixelGetter(dst)
+	db := dst.Bounds()
+	for i := range lut.Cube {
+		x, y := db.Min.X+i%(size*size), db.Min.Y+i/(size*size)
+		if image.Pt(x, y).In(db) {
+			px := pixGetter.getPixel(x, y)
+			lut.Cube[i] = [3]float32{px.R, px.G, px.B}
+		}
+	}
+	return lut
+}
+
+// ReadCubeLUT reads a color lookup table in the Adobe / Resolve .cube format.
+// The files containing a 1D table, a 3D table or both (a 1D shaper followed by a 3D table) are supported.
+func ReadCubeLUT(r io.Reader) (*ColorLUT, error) {
+	lut := &ColorLUT{
+		ShaperMax: [3]float32{1, 1, 1},
+		CubeMax:   [3]float32{1, 1, 1},
+	}
+	size1D := 0
+	var data [][3]float32
+
+	parseFloats := func(fields []string, vals []float32) error {
+		if len(fields) != len(vals) {
+			return fmt.Errorf("expected %d values, got %d", len(vals), len(fields))
+		}
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				return err
+			}
+			vals[i] = float32(v)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(s)
+		var err error
+		switch fields[0] {
+		case "TITLE":
+			lut.Title = strings.Trim(strings.TrimSpace(s[len("TITLE"):]), "\"")
+		case "LUT_1D_SIZE":
+			size1D, err = strconv.Atoi(strings.Join(fields[1:], " "))
+			if err == nil && (size1D < 2 || size1D > 65536) {
+				err = fmt.Errorf("invalid 1D table size %d", size1D)
+			}
+		case "LUT_3D_SIZE":
+			lut.Size, err = strconv.Atoi(strings.Join(fields[1:], " "))
+			if err == nil && (lut.Size < 2 || lut.Size > 256) {
+				err = fmt.Errorf("invalid 3D table size %d", lut.Size)
+			}
+		case "DOMAIN_MIN":
+			err = parseFloats(fields[1:], lut.CubeMin[:])
+			lut.ShaperMin = lut.CubeMin
+		case "DOMAIN_MAX":
+			err = parseFloats(fields[1:], lut.CubeMax[:])
+			lut.ShaperMax = lut.CubeMax
+		case "LUT_1D_INPUT_RANGE", "LUT_3D_INPUT_RANGE":
+			var rng [2]float32
+			err = parseFloats(fields[1:], rng[:])
+			min, max := &lut.CubeMin, &lut.CubeMax
+			if fields[0] == "LUT_1D_INPUT_RANGE" {
+				min, max = &lut.ShaperMin, &lut.ShaperMax
+			}
+			*min = [3]float32{rng[0], rng[0], rng[0]}
+			*max = [3]float32{rng[1], rng[1], rng[1]}
+		default:
+			c := s[0]
+			if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' {
+				// unknown keyword
+				continue
+			}
+			var v [3]float32
+			err = parseFloats(fields, v[:])
+			data = append(data, v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gift: .cube line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	size3D := lut.Size * lut.Size * lut.Size
+	if size1D == 0 && lut.Size == 0 {
+		return nil, fmt.Errorf("gift: .cube: missing LUT_1D_SIZE or LUT_3D_SIZE")
+	}
+	if len(data) != size1D+size3D {
+		return nil, fmt.Errorf("gift: .cube: expected %d table entries, got %d", size1D+size3D, len(data))
+	}
+	if size1D > 0 {
+		lut.Shaper = data[:size1D]
+	}
+	if size3D > 0 {
+		lut.Cube = data[size1D:]
+	}
+	return lut, nil
+}
+
+// WriteCube writes the color lookup table in the .cube format.
+// The tables containing both 1D and 3D tables are written in the Resolve format,
+// which uses a single input range for all the channels.
+func (lut *ColorLUT) WriteCube(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	has1D := len(lut.Shaper) > 0
+	has3D := lut.Size > 0
+
+	if lut.Title != "" {
+		fmt.Fprintf(bw, "TITLE \"%s\"\n", lut.Title)
+	}
+	if has1D {
+		fmt.Fprintf(bw, "LUT_1D_SIZE %d\n", len(lut.Shaper))
+	}
+	if has3D {
+		fmt.Fprintf(bw, "LUT_3D_SIZE %d\n", lut.Size)
+	}
+
+	isDefault := func(min, max [3]float32) bool {
+		return min == [3]float32{0, 0, 0} && max == [3]float32{1, 1, 1}
+	}
+	switch {
+	case has1D && has3D:
+		if !isDefault(lut.ShaperMin, lut.ShaperMax) {
+			fmt.Fprintf(bw, "LUT_1D_INPUT_RANGE %s %s\n", formatCubeFloat(lut.ShaperMin[0]), formatCubeFloat(lut.ShaperMax[0]))
+		}
+		if !isDefault(lut.CubeMin, lut.CubeMax) {
+			fmt.Fprintf(bw, "LUT_3D_INPUT_RANGE %s %s\n", formatCubeFloat(lut.CubeMin[0]), formatCubeFloat(lut.CubeMax[0]))
+		}
+	case has1D && !isDefault(lut.ShaperMin, lut.ShaperMax):
+		writeCubeLine(bw, "DOMAIN_MIN ", lut.ShaperMin)
+		writeCubeLine(bw, "DOMAIN_MAX ", lut.ShaperMax)
+	case has3D && !isDefault(lut.CubeMin, lut.CubeMax):
+		writeCubeLine(bw, "DOMAIN_MIN ", lut.CubeMin)
+		writeCubeLine(bw, "DOMAIN_MAX ", lut.CubeMax)
+	}
+
+	for _, c := range lut.Shaper {
+		writeCubeLine(bw, "", c)
+	}
+	if has3D {
+		for _, c := range lut.Cube {
+			writeCubeLine(bw, "", c)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeCubeLine(w *bufio.Writer, prefix string, c [3]float32) {
+	fmt.Fprintf(w, "%s%s %s %s\n", prefix, formatCubeFloat(c[0]), formatCubeFloat(c[1]), formatCubeFloat(c[2]))
+}
+
+func formatCubeFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', 6, 32)
+}
@@ -0,0 +1,199 @@
+package gift
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLUT3DBake(t *testing.T) {
+	src := loadImage(t, "testdata/src.png")
+
+	testData := []struct {
+		desc   string
+		filter Filter
+		dif    int
+	}{
+		{"identity", ColorFunc(func(r, g, b, a float32) (float32, float32, float32, float32) { return r, g, b, a }), 1},
+		{"invert", Invert(), 1},
+		{"color balance", ColorBalance(20, -10, 5), 1},
+		{"saturation", Saturation(30), 4},
+		{"hue", Hue(45), 6},
+	}
+
+	for _, d := range testData {
+		g := New(d.filter)
+		want := image.NewNRGBA(g.Bounds(src.Bounds()))
+		g.Draw(want, src)
+
+		lut := BakeLUT3D(33, d.filter)
+		for _, interp := range []LUTInterpolation{TrilinearInterpolation, TetrahedralInterpolation} {
+			g := New(LUT3D(lut, interp))
+			got := image.NewNRGBA(g.Bounds(src.Bounds()))
+			g.Draw(got, src)
+			for i := range want.Pix {
+				if absf32(float32(want.Pix[i])-float32(got.Pix[i])) > float32(d.dif) {
+					t.Errorf("test [%s] interpolation %d failed at index %d: expected %d got %d", d.desc, interp, i, want.Pix[i], got.Pix[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestLUT3DInterpolation(t *testing.T) {
+	// 2x2x2 table mapping every color to its luminance
+	lut := BakeLUT3D(2, Grayscale())
+	px := pixel{0.2, 0.6, 0.4, 1}
+
+	got := LUT3D(lut, TetrahedralInterpolation).(*colorFilter).fn(px)
+	want := 0.299*px.R + 0.587*px.G + 0.114*px.B
+	if !comparePixels(got, pixel{want, want, want, 1}, 1e-3) {
+		t.Errorf("tetrahedral: expected %v got %v", want, got)
+	}
+
+	got = LUT3D(lut, TrilinearInterpolation).(*colorFilter).fn(px)
+	if !comparePixels(got, pixel{want, want, want, 1}, 1e-3) {
+		t.Errorf("trilinear: expected %v got %v", want, got)
+	}
+}
+
+func TestReadCubeLUT(t *testing.T) {
+	testData := []struct {
+		desc string
+		src  string
+		want *ColorLUT
+	}{
+		{
+			"3d",
+			`# comment
+TITLE "Swap red and blue"
+LUT_3D_SIZE 2
+
+0 0 0
+0 0 1
+0 1 0
+0 1 1
+1 0 0
+1 0 1
+1 1 0
+1 1 1
+`,
+			&ColorLUT{
+				Title:     "Swap red and blue",
+				ShaperMax: [3]float32{1, 1, 1},
+				Size:      2,
+				Cube: [][3]float32{
+					{0, 0, 0}, {0, 0, 1}, {0, 1, 0}, {0, 1, 1},
+					{1, 0, 0}, {1, 0, 1}, {1, 1, 0}, {1, 1, 1},
+				},
+				CubeMax: [3]float32{1, 1, 1},
+			},
+		},
+		{
+			"1d",
+			`LUT_1D_SIZE 3
+DOMAIN_MIN 0 0 0
+DOMAIN_MAX 2 2 2
+0 0 0
+0.25 0.5 0.75
+1 1 1
+`,
+			&ColorLUT{
+				Shaper:    [][3]float32{{0, 0, 0}, {0.25, 0.5, 0.75}, {1, 1, 1}},
+				ShaperMax: [3]float32{2, 2, 2},
+				CubeMax:   [3]float32{2, 2, 2},
+			},
+		},
+		{
+			"shaper",
+			`LUT_1D_SIZE 2
+LUT_3D_SIZE 2
+LUT_1D_INPUT_RANGE 0 4
+LUT_IN_VIDEO_RANGE
+0 0 0
+1 1 1
+0 0 0
+1 0 0
+0 1 0
+1 1 0
+0 0 1
+1 0 1
+0 1 1
+1 1 1
+`,
+			&ColorLUT{
+				Shaper:    [][3]float32{{0, 0, 0}, {1, 1, 1}},
+				ShaperMax: [3]float32{4, 4, 4},
+				Size:      2,
+				Cube: [][3]float32{
+					{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0},
+					{0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {1, 1, 1},
+				},
+				CubeMax: [3]float32{1, 1, 1},
+			},
+		},
+	}
+
+	for _, d := range testData {
+		lut, err := ReadCubeLUT(strings.NewReader(d.src))
+		if err != nil {
+			t.Errorf("test [%s] failed: %v", d.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(lut, d.want) {
+			t.Errorf("test [%s] failed: expected %#v got %#v", d.desc, d.want, lut)
+		}
+
+		var buf bytes.Buffer
+		if err := lut.WriteCube(&buf); err != nil {
+			t.Errorf("test [%s] write failed: %v", d.desc, err)
+			continue
+		}
+		lut2, err := ReadCubeLUT(&buf)
+		if err != nil {
+			t.Errorf("test [%s] read after write failed: %v", d.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(lut, lut2) {
+			t.Errorf("test [%s] write round trip failed: expected %#v got %#v", d.desc, lut, lut2)
+		}
+	}
+
+	// the swap table applied to a color
+	lut, _ := ReadCubeLUT(strings.NewReader(testData[0].src))
+	got := LUT3D(lut, TrilinearInterpolation).(*colorFilter).fn(pixel{0.2, 0.5, 0.9, 0.5})
+	if !comparePixels(got, pixel{0.9, 0.5, 0.2, 0.5}, 1e-5) {
+		t.Errorf("swap table: expected %v got %v", pixel{0.9, 0.5, 0.2, 0.5}, got)
+	}
+
+	// the 1D table with the domain [0, 2]
+	lut, _ = ReadCubeLUT(strings.NewReader(testData[1].src))
+	got = LUT3D(lut, TrilinearInterpolation).(*colorFilter).fn(pixel{0.5, 1, 2, 1})
+	if !comparePixels(got, pixel{0.125, 0.5, 1, 1}, 1e-5) {
+		t.Errorf("1d table: expected %v got %v", pixel{0.125, 0.5, 1, 1}, got)
+	}
+}
+
+func TestReadCubeLUTErrors(t *testing.T) {
+	testData := []struct {
+		desc string
+		src  string
+	}{
+		{"empty", ""},
+		{"no size", "0 0 0\n1 1 1\n"},
+		{"bad size", "LUT_3D_SIZE 1\n0 0 0\n"},
+		{"bad number", "LUT_1D_SIZE 2\n0 0 0\n1 x 1\n"},
+		{"short line", "LUT_1D_SIZE 2\n0 0 0\n1 1\n"},
+		{"missing entries", "LUT_3D_SIZE 2\n0 0 0\n1 1 1\n"},
+		{"bad domain", "DOMAIN_MIN 0 0\nLUT_1D_SIZE 2\n0 0 0\n1 1 1\n"},
+	}
+
+	for _, d := range testData {
+		if _, err := ReadCubeLUT(strings.NewReader(d.src)); err == nil {
+			t.Errorf("test [%s] failed: expected an error", d.desc)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+)
+
+// readPixels reads the w x h pixels of the image starting from its Min point.
+func readPixels(img image.Image, w, h int, options *Options) []pixel {
+	b := img.Bounds()
+	pixGetter := newPixelGetter(img)
+	pixels := make([]pixel, w*h)
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				pixels[y*w+x] = pixGetter.getPixel(b.Min.X+x, b.Min.Y+y)
+			}
+		}
+	})
+	return pixels
+}
+
+// comparedPixels reads the pixels of the common area of two images.
+func comparedPixels(img1, img2 image.Image) (w, h int, p1, p2 []pixel) {
+	w = minint(img1.Bounds().Dx(), img2.Bounds().Dx())
+	h = minint(img1.Bounds().Dy(), img2.Bounds().Dy())
+	if w <= 0 || h <= 0 {
+		return 0, 0, nil, nil
+	}
+	p1 = readPixels(img1, w, h, &defaultOptions)
+	p2 = readPixels(img2, w, h, &defaultOptions)
+	return
+}
+
+// MSE calculates the mean squared error between two images.
+// The red, green and blue channels of the images composed over black are compared, the values are in range [0, 1].
+// The images are compared pixel by pixel starting from their Min points over the area common to both images.
+// MSE returns NaN if the common area is empty.
+func MSE(img1, img2 image.Image) float64 {
+	w, h, p1, p2 := comparedPixels(img1, img2)
+	if w == 0 {
+		return math.NaN()
+	}
+	var mu sync.Mutex
+	var sum float64
+	parallelize(defaultOptions.Parallelization, 0, h, func(pmin, pmax int) {
+		var s float64
+		for i := pmin * w; i < pmax*w; i++ {
+			a, b := p1[i], p2[i]
+			dr := float64(a.R*a.A - b.R*b.A)
+			dg := float64(a.G*a.A - b.G*b.A)
+			db := float64(a.B*a.A - b.B*b.A)
+			s += dr*dr + dg*dg + db*db
+		}
+		mu.Lock()
+		sum += s
+		mu.Unlock()
+	})
+	return sum / float64(3*w*h)
+}
+
+// PSNR calculates the peak signal-to-noise ratio between two images in decibels.
+// It returns +Inf for identical images. See MSE for details.
+func PSNR(img1, img2 image.Image) float64 {
+	mse := MSE(img1, img2)
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return -10 * math.Log10(mse)
+}
+
+// lumaPlane returns the luminance of the pixels composed over black.
+func lumaPlane(pixels []pixel) []float64 {
+	plane := make([]float64, len(pixels))
+	for i, px := range pixels {
+		plane[i] = float64((0.299*px.R + 0.587*px.G + 0.114*px.B) * px.A)
+	}
+	return plane
+}
+
+// blurPlane convolves the plane with the separable kernel, clamping the coordinates at the edges.
+func blurPlane(plane []float64, w, h int, kernel []float32) []float64 {
+	r := len(kernel) / 2
+	tmp := make([]float64, len(plane))
+	dst := make([]float64, len(plane))
+	parallelize(defaultOptions.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				var s float64
+				for k, kv := range kernel {
+					xx := minint(maxint(x+k-r, 0), w-1)
+					s += plane[y*w+xx] * float64(kv)
+				}
+				tmp[y*w+x] = s
+			}
+		}
+	})
+	parallelize(defaultOptions.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				var s float64
+				for k, kv := range kernel {
+					yy := minint(maxint(y+k-r, 0), h-1)
+					s += tmp[yy*w+x] * float64(kv)
+				}
+				dst[y*w+x] = s
+			}
+		}
+	})
+	return dst
+}
+
+// SSIM constants for the values in range [0, 1].
+const (
+	ssimSigma = 1.5
+	ssimC1    = 0.01 * 0.01
+	ssimC2    = 0.03 * 0.03
+)
+
+// ssim returns the mean structural similarity and the mean contrast-structure component of two planes.
+func ssim(y1, y2 []float64, w, h int) (ssimMean, csMean float64) {
+	kernel := gaussianBlurKernel1d(ssimSigma)
+	prod := func(a, b []float64) []float64 {
+		p := make([]float64, len(a))
+		for i := range a {
+			p[i] = a[i] * b[i]
+		}
+		return p
+	}
+	mu1 := blurPlane(y1, w, h, kernel)
+	mu2 := blurPlane(y2, w, h, kernel)
+	s11 := blurPlane(prod(y1, y1), w, h, kernel)
+	s22 := blurPlane(prod(y2, y2), w, h, kernel)
+	s12 := blurPlane(prod(y1, y2), w, h, kernel)
+
+	for i := range mu1 {
+		m1, m2 := mu1[i], mu2[i]
+		v1 := s11[i] - m1*m1
+		v2 := s22[i] - m2*m2
+		cov := s12[i] - m1*m2
+		cs := (2*cov + ssimC2) / (v1 + v2 + ssimC2)
+		ssimMean += (2*m1*m2 + ssimC1) / (m1*m1 + m2*m2 + ssimC1) * cs
+		csMean += cs
+	}
+	n := float64(len(mu1))
+	return ssimMean / n, csMean / n
+}
+
+// SSIM calculates the structural similarity index between the luminance of two images
+// using a gaussian window with sigma = 1.5. The result is 1 for identical images.
+// See MSE for the details of the comparison.
+func SSIM(img1, img2 image.Image) float64 {
+	w, h, p1, p2 := comparedPixels(img1, img2)
+	if w == 0 {
+		return math.NaN()
+	}
+	s, _ := ssim(lumaPlane(p1), lumaPlane(p2), w, h)
+	return s
+}
+
+// msssimWeights are the weights of the MS-SSIM scales.
+var msssimWeights = []float64{0.0448, 0.2856, 0.3001, 0.2363, 0.1333}
+
+// halvePlane downsamples the plane by the factor of 2 using 2x2 box averaging.
+func halvePlane(plane []float64, w, h int) ([]float64, int, int) {
+	nw, nh := w/2, h/2
+	dst := make([]float64, nw*nh)
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			i := 2*y*w + 2*x
+			dst[y*nw+x] = (plane[i] + plane[i+1] + plane[i+w] + plane[i+w+1]) / 4
+		}
+	}
+	return dst, nw, nh
+}
+
+// MSSSIM calculates the multi-scale structural similarity index between the luminance of two images.
+// Up to 5 scales are used, fewer if the images are too small to be downsampled.
+// The result is 1 for identical images. See MSE for the details of the comparison.
+func MSSSIM(img1, img2 image.Image) float64 {
+	w, h, p1, p2 := comparedPixels(img1, img2)
+	if w == 0 {
+		return math.NaN()
+	}
+	y1, y2 := lumaPlane(p1), lumaPlane(p2)
+
+	scales := 1
+	for sw, sh := w, h; scales < len(msssimWeights) && minint(sw, sh) >= 32; scales++ {
+		sw, sh = sw/2, sh/2
+	}
+	var wsum float64
+	for _, wt := range msssimWeights[:scales] {
+		wsum += wt
+	}
+
+	result := 1.0
+	for s := 0; s < scales; s++ {
+		ss, cs := ssim(y1, y2, w, h)
+		wt := msssimWeights[s] / wsum
+		if s == scales-1 {
+			result *= math.Pow(math.Max(ss, 0), wt)
+			break
+		}
+		result *= math.Pow(math.Max(cs, 0), wt)
+		y1, _, _ = halvePlane(y1, w, h)
+		y2, w, h = halvePlane(y2, w, h)
+	}
+	return result
+}
+
+// ciede2000 calculates the CIEDE2000 color difference of two L*a*b* colors.
+func ciede2000(l1, a1, b1, l2, a2, b2 float64) float64 {
+	const deg = math.Pi / 180
+	pow25x7 := math.Pow(25, 7)
+
+	c1 := math.Hypot(a1, b1)
+	c2 := math.Hypot(a2, b2)
+	cb7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cb7/(cb7+pow25x7)))
+	a1p, a2p := (1+g)*a1, (1+g)*a2
+	c1p, c2p := math.Hypot(a1p, b1), math.Hypot(a2p, b2)
+
+	hue := func(b, ap float64) float64 {
+		if b == 0 && ap == 0 {
+			return 0
+		}
+		h := math.Atan2(b, ap) / deg
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	h1p, h2p := hue(b1, a1p), hue(b2, a2p)
+
+	dlp := l2 - l1
+	dcp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(dhp/2*deg)
+
+	lbp := (l1 + l2) / 2
+	cbp := (c1p + c2p) / 2
+	hbp := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hbp /= 2
+		case hbp < 360:
+			hbp = (hbp + 360) / 2
+		default:
+			hbp = (hbp - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos((hbp-30)*deg) + 0.24*math.Cos(2*hbp*deg) +
+		0.32*math.Cos((3*hbp+6)*deg) - 0.20*math.Cos((4*hbp-63)*deg)
+	dtheta := 30 * math.Exp(-((hbp-275)/25)*((hbp-275)/25))
+	cbp7 := math.Pow(cbp, 7)
+	rc := 2 * math.Sqrt(cbp7/(cbp7+pow25x7))
+	lb50 := (lbp - 50) * (lbp - 50)
+	sl := 1 + 0.015*lb50/math.Sqrt(20+lb50)
+	sc := 1 + 0.045*cbp
+	sh := 1 + 0.015*cbp*t
+	rt := -math.Sin(2*dtheta*deg) * rc
+
+	dl, dc, dh := dlp/sl, dcp/sc, dHp/sh
+	return math.Sqrt(dl*dl + dc*dc + dh*dh + rt*dc*dh)
+}
+
+// pixelLab converts the pixel composed over black to L*a*b*.
+func pixelLab(px pixel) (float64, float64, float64) {
+	return srgbToLab(float64(px.R*px.A), float64(px.G*px.A), float64(px.B*px.A))
+}
+
+// DeltaE2000 calculates the mean and the maximum CIEDE2000 color difference between the pixels of two images.
+// The colors are treated as sRGB with the D65 white point. A difference of about 1 is just noticeable.
+// See MSE for the details of the comparison.
+func DeltaE2000(img1, img2 image.Image) (mean, max float64) {
+	w, h, p1, p2 := comparedPixels(img1, img2)
+	if w == 0 {
+		return math.NaN(), math.NaN()
+	}
+	var mu sync.Mutex
+	parallelize(defaultOptions.Parallelization, 0, h, func(pmin, pmax int) {
+		var s, m float64
+		for i := pmin * w; i < pmax*w; i++ {
+			l1, a1, b1 := pixelLab(p1[i])
+			l2, a2, b2 := pixelLab(p2[i])
+			d := ciede2000(l1, a1, b1, l2, a2, b2)
+			s += d
+			m = math.Max(m, d)
+		}
+		mu.Lock()
+		mean += s
+		max = math.Max(max, m)
+		mu.Unlock()
+	})
+	return mean / float64(w*h), max
+}
+
+// heatColor maps the value in range [0, 1] to the black-blue-green-yellow-red color scale.
+func heatColor(v float32) pixel {
+	stops := [...]pixel{{0, 0, 0, 1}, {0, 0, 1, 1}, {0, 1, 0, 1}, {1, 1, 0, 1}, {1, 0, 0, 1}}
+	v = minf32(maxf32(v, 0), 1) * float32(len(stops)-1)
+	i := minint(int(v), len(stops)-2)
+	t := v - float32(i)
+	c0, c1 := stops[i], stops[i+1]
+	return pixel{c0.R + (c1.R-c0.R)*t, c0.G + (c1.G-c0.G)*t, c0.B + (c1.B-c0.B)*t, 1}
+}
+
+type differenceHeatmapFilter struct {
+	reference image.Image
+	scale     float32
+}
+
+func (p *differenceHeatmapFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *differenceHeatmapFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	refb := p.reference.Bounds()
+	dstb := dst.Bounds()
+	srcGetter := newPixelGetter(src)
+	refGetter := newPixelGetter(p.reference)
+	pixSetter := newPixelSetter(dst)
+
+	parallelize(options.Parallelization, 0, srcb.Dy(), func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < srcb.Dx(); x++ {
+				var d float32 = 1
+				if x < refb.Dx() && y < refb.Dy() {
+					a := srcGetter.getPixel(srcb.Min.X+x, srcb.Min.Y+y)
+					b := refGetter.getPixel(refb.Min.X+x, refb.Min.Y+y)
+					d = maxf32(absf32(a.R*a.A-b.R*b.A), maxf32(absf32(a.G*a.A-b.G*b.A), absf32(a.B*a.A-b.B*b.A)))
+					d = maxf32(d, absf32(a.A-b.A))
+				}
+				pixSetter.setPixel(dstb.Min.X+x, dstb.Min.Y+y, heatColor(d*p.scale))
+			}
+		}
+	})
+}
+
+// DifferenceHeatmap creates a filter that renders the per-pixel difference between an image and the reference image
+// as a heatmap: black for equal pixels, then blue, green, yellow and red for increasing differences.
+// The difference is the largest absolute difference of the channels, multiplied by the scale parameter;
+// e.g. the scale = 10 renders the differences of 10% and more as red.
+// The pixels outside of the reference image are red.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.DifferenceHeatmap(expected, 10),
+//	)
+//	dst := image.NewRGBA(g.Bounds(actual.Bounds()))
+//	g.Draw(dst, actual)
+//
+func DifferenceHeatmap(reference image.Image, scale float32) Filter {
+	return &differenceHeatmapFilter{
+		reference: reference,
+		scale:     scale,
+	}
+}
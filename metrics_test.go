@@ -0,0 +1,153 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestCIEDE2000(t *testing.T) {
+	// reference data from Sharma, Wu and Dalal, "The CIEDE2000 color-difference formula"
+	testData := []struct {
+		l1, a1, b1, l2, a2, b2, de float64
+	}{
+		{50, 2.6772, -79.7751, 50, 0, -82.7485, 2.0425},
+		{50, 3.1571, -77.2803, 50, 0, -82.7485, 2.8615},
+		{50, 2.8361, -74.0200, 50, 0, -82.7485, 3.4412},
+		{50, -1.3802, -84.2814, 50, 0, -82.7485, 1.0000},
+		{50, 0, 0, 50, -1, 2, 2.3669},
+		{50, 2.4900, -0.0010, 50, -2.4900, 0.0009, 7.1792},
+		{50, 2.5, 0, 73, 25, -18, 27.1492},
+		{60.2574, -34.0099, 36.2677, 60.4626, -34.1751, 39.4387, 1.2644},
+		{22.7233, 20.0904, -46.6940, 23.0331, 14.9730, -42.5619, 2.0373},
+		{2.0776, 0.0795, -1.1350, 0.9033, -0.0636, -0.5514, 0.9082},
+	}
+	for _, d := range testData {
+		de := ciede2000(d.l1, d.a1, d.b1, d.l2, d.a2, d.b2)
+		if math.Abs(de-d.de) > 1e-4 {
+			t.Errorf("ciede2000 %v: expected %v got %v", d, d.de, de)
+		}
+		if de2 := ciede2000(d.l2, d.a2, d.b2, d.l1, d.a1, d.b1); math.Abs(de-de2) > 1e-9 {
+			t.Errorf("ciede2000 %v: not symmetric: %v, %v", d, de, de2)
+		}
+	}
+
+	l, a, b := srgbToLab(1, 1, 1)
+	if math.Abs(l-100) > 1e-3 || math.Abs(a) > 1e-3 || math.Abs(b) > 1e-3 {
+		t.Errorf("srgbToLab white: expected (100, 0, 0) got (%v, %v, %v)", l, a, b)
+	}
+	l, a, b = srgbToLab(1, 0, 0)
+	if math.Abs(l-53.24) > 0.01 || math.Abs(a-80.09) > 0.01 || math.Abs(b-67.20) > 0.01 {
+		t.Errorf("srgbToLab red: expected (53.24, 80.09, 67.20) got (%v, %v, %v)", l, a, b)
+	}
+}
+
+func genMetricsTestImage(w, h int, noise int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	seed := uint32(3)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1664525 + 1013904223
+			v := 64 + (x*3+y*2)%128 + int(seed>>24)%(noise+1) - noise/2
+			img.SetGray(x, y, color.Gray{uint8(v)})
+		}
+	}
+	return img
+}
+
+func TestMetrics(t *testing.T) {
+	black := image.NewGray(image.Rect(0, 0, 4, 4))
+	white := image.NewGray(image.Rect(2, 2, 6, 6))
+	for i := range white.Pix {
+		white.Pix[i] = 0xff
+	}
+
+	if v := MSE(black, white); v != 1 {
+		t.Errorf("MSE black/white: expected 1 got %v", v)
+	}
+	if v := MSE(black, black); v != 0 {
+		t.Errorf("MSE black/black: expected 0 got %v", v)
+	}
+	if v := PSNR(black, white); v != 0 {
+		t.Errorf("PSNR black/white: expected 0 got %v", v)
+	}
+	if v := PSNR(white, white); !math.IsInf(v, 1) {
+		t.Errorf("PSNR white/white: expected +Inf got %v", v)
+	}
+	if v := MSE(black, image.NewGray(image.Rect(0, 0, 0, 4))); !math.IsNaN(v) {
+		t.Errorf("MSE empty: expected NaN got %v", v)
+	}
+	if mean, max := DeltaE2000(black, white); math.Abs(mean-100) > 1e-3 || math.Abs(max-100) > 1e-3 {
+		t.Errorf("DeltaE2000 black/white: expected 100, 100 got %v, %v", mean, max)
+	}
+	if mean, max := DeltaE2000(white, white); mean != 0 || max != 0 {
+		t.Errorf("DeltaE2000 white/white: expected 0, 0 got %v, %v", mean, max)
+	}
+
+	img := genMetricsTestImage(96, 80, 0)
+	noisy := genMetricsTestImage(96, 80, 40)
+	blurred := image.NewGray(img.Bounds())
+	New(GaussianBlur(2)).Draw(blurred, img)
+
+	if v := SSIM(img, img); math.Abs(v-1) > 1e-9 {
+		t.Errorf("SSIM identical: expected 1 got %v", v)
+	}
+	if v := MSSSIM(img, img); math.Abs(v-1) > 1e-9 {
+		t.Errorf("MSSSIM identical: expected 1 got %v", v)
+	}
+	for _, d := range []struct {
+		desc string
+		img  image.Image
+	}{{"noisy", noisy}, {"blurred", blurred}} {
+		if v := SSIM(img, d.img); v <= 0 || v >= 0.99 {
+			t.Errorf("SSIM %s: expected value in (0, 0.99) got %v", d.desc, v)
+		}
+		if v := MSSSIM(img, d.img); v <= 0 || v >= 0.999 {
+			t.Errorf("MSSSIM %s: expected value in (0, 0.999) got %v", d.desc, v)
+		}
+		if v := PSNR(img, d.img); v < 10 || v > 40 {
+			t.Errorf("PSNR %s: expected value in (10, 40) got %v", d.desc, v)
+		}
+	}
+	if SSIM(img, noisy) <= SSIM(img, genMetricsTestImage(96, 80, 120)) {
+		t.Error("SSIM: more noise must give lower similarity")
+	}
+	if v := SSIM(black, black); math.Abs(v-1) > 1e-9 {
+		t.Errorf("SSIM black: expected 1 got %v", v)
+	}
+}
+
+func TestDifferenceHeatmap(t *testing.T) {
+	ref := image.NewGray(image.Rect(0, 0, 3, 1))
+	copy(ref.Pix, []uint8{0x00, 0x00, 0x00})
+	src := image.NewGray(image.Rect(5, 5, 9, 6))
+	copy(src.Pix, []uint8{0x00, 0x40, 0xff, 0x00})
+
+	g := New(DifferenceHeatmap(ref, 1))
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	want := []uint8{
+		0x00, 0x00, 0x00, 0xff,
+		0x00, 0x01, 0xfe, 0xff,
+		0xff, 0x00, 0x00, 0xff,
+		0xff, 0x00, 0x00, 0xff,
+	}
+	if !comparePix(dst.Pix, want) {
+		t.Errorf("DifferenceHeatmap: expected %v got %v", want, dst.Pix)
+	}
+}
+
+func TestGoldenTolerance(t *testing.T) {
+	src := loadImage(t, "testdata/src.png")
+	want := loadImage(t, "testdata/dst_gaussian_blur.png")
+	g := New(GaussianBlur(1))
+	got := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(got, src)
+	if v := PSNR(got, want); v < 50 {
+		t.Errorf("gaussian blur: PSNR against the golden image: expected > 50 got %v", v)
+	}
+	if _, max := DeltaE2000(got, want); max > 1 {
+		t.Errorf("gaussian blur: max CIEDE2000 against the golden image: expected < 1 got %v", max)
+	}
+}
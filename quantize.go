@@ -0,0 +1,476 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// DitherMethod is a dithering algorithm used to reduce the banding of images drawn with a limited palette.
+type DitherMethod int
+
+// Dithering methods.
+const (
+	NoDither DitherMethod = iota
+	FloydSteinbergDither
+	AtkinsonDither
+	BayerDither
+)
+
+// PaletteMethod is an algorithm used to generate a palette from the colors of an image.
+type PaletteMethod int
+
+// Palette generation methods.
+const (
+	MedianCutPalette PaletteMethod = iota
+	OctreePalette
+	KMeansPalette
+)
+
+// maxPaletteSamples limits the number of pixels used to generate a palette.
+const maxPaletteSamples = 1 << 18
+
+// paletteSamples returns the pixels of the image, evenly skipping pixels of large images.
+func paletteSamples(img image.Image) []pixel {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil
+	}
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > maxPaletteSamples {
+		step++
+	}
+	pixGetter := newPixelGetter(img)
+	samples := make([]pixel, 0, ((b.Dx()+step-1)/step)*((b.Dy()+step-1)/step))
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			samples = append(samples, pixGetter.getPixel(x, y))
+		}
+	}
+	return samples
+}
+
+// meanPixel returns the alpha-weighted mean of the pixels.
+func meanPixel(pixels []pixel) pixel {
+	var r, g, b, a float64
+	for _, px := range pixels {
+		r += float64(px.R * px.A)
+		g += float64(px.G * px.A)
+		b += float64(px.B * px.A)
+		a += float64(px.A)
+	}
+	if a == 0 {
+		return pixel{0, 0, 0, 0}
+	}
+	return pixel{float32(r / a), float32(g / a), float32(b / a), float32(a / float64(len(pixels)))}
+}
+
+func pixelChannel(px *pixel, ch int) float32 {
+	switch ch {
+	case 0:
+		return px.R
+	case 1:
+		return px.G
+	case 2:
+		return px.B
+	}
+	return px.A
+}
+
+// medianCut splits the color space into at most n boxes containing equal numbers of pixels.
+func medianCut(samples []pixel, n int) []pixel {
+	if len(samples) == 0 {
+		return nil
+	}
+	boxes := [][]pixel{samples}
+	for len(boxes) < n {
+		best, bestCh := -1, 0
+		var bestRange float32
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 4; ch++ {
+				lo, hi := pixelChannel(&box[0], ch), pixelChannel(&box[0], ch)
+				for j := range box {
+					v := pixelChannel(&box[j], ch)
+					lo = minf32(lo, v)
+					hi = maxf32(hi, v)
+				}
+				if hi-lo > bestRange {
+					best, bestCh, bestRange = i, ch, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool {
+			return pixelChannel(&box[i], bestCh) < pixelChannel(&box[j], bestCh)
+		})
+		m := len(box) / 2
+		boxes[best] = box[:m]
+		boxes = append(boxes, box[m:])
+	}
+
+	pal := make([]pixel, len(boxes))
+	for i, box := range boxes {
+		pal[i] = meanPixel(box)
+	}
+	return pal
+}
+
+type octreeNode struct {
+	children   [8]*octreeNode
+	r, g, b, a float64
+	count      int
+	leaf       bool
+}
+
+func (node *octreeNode) nchildren() (n int) {
+	for _, c := range node.children {
+		if c != nil {
+			n++
+		}
+	}
+	return
+}
+
+// octree builds an octree of the 8-bit RGB colors and merges the least populated nodes
+// until at most n leaves remain.
+func octree(samples []pixel, n int) []pixel {
+	if len(samples) == 0 {
+		return nil
+	}
+	root := &octreeNode{}
+	levels := make([][]*octreeNode, 8)
+	leaves := 0
+	for _, px := range samples {
+		r8, g8, b8 := f32u8(px.R*255), f32u8(px.G*255), f32u8(px.B*255)
+		node := root
+		for level := 0; level < 8; level++ {
+			shift := 7 - uint(level)
+			i := (r8>>shift&1)<<2 | (g8>>shift&1)<<1 | b8>>shift&1
+			if node.children[i] == nil {
+				node.children[i] = &octreeNode{leaf: level == 7}
+				if level < 7 {
+					levels[level+1] = append(levels[level+1], node.children[i])
+				} else {
+					leaves++
+				}
+			}
+			node = node.children[i]
+		}
+		node.r += float64(px.R * px.A)
+		node.g += float64(px.G * px.A)
+		node.b += float64(px.B * px.A)
+		node.a += float64(px.A)
+		node.count++
+	}
+
+	var collect func(node *octreeNode)
+	collect = func(node *octreeNode) {
+		if node.leaf {
+			return
+		}
+		for _, c := range node.children {
+			if c != nil {
+				collect(c)
+				node.r += c.r
+				node.g += c.g
+				node.b += c.b
+				node.a += c.a
+				node.count += c.count
+			}
+		}
+	}
+	collect(root)
+	levels[0] = []*octreeNode{root}
+
+	for level := 7; level >= 0 && leaves > n; level-- {
+		nodes := levels[level]
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+		for _, node := range nodes {
+			if leaves <= n {
+				break
+			}
+			leaves -= node.nchildren() - 1
+			node.children = [8]*octreeNode{}
+			node.leaf = true
+		}
+	}
+
+	var pal []pixel
+	var walk func(node *octreeNode)
+	walk = func(node *octreeNode) {
+		if node.leaf {
+			px := pixel{0, 0, 0, 0}
+			if node.a > 0 {
+				px = pixel{float32(node.r / node.a), float32(node.g / node.a), float32(node.b / node.a), float32(node.a / float64(node.count))}
+			}
+			pal = append(pal, px)
+			return
+		}
+		for _, c := range node.children {
+			if c != nil {
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+	return pal
+}
+
+// kmeans refines the median cut palette using Lloyd's algorithm.
+func kmeans(samples []pixel, n int) []pixel {
+	pal := medianCut(append([]pixel(nil), samples...), n)
+	if len(pal) == 0 {
+		return pal
+	}
+	const iterations = 8
+	clusters := make([][]pixel, len(pal))
+	for it := 0; it < iterations; it++ {
+		for i := range clusters {
+			clusters[i] = clusters[i][:0]
+		}
+		for _, px := range samples {
+			k := getPaletteIndex(pal, px)
+			clusters[k] = append(clusters[k], px)
+		}
+		for i, c := range clusters {
+			if len(c) > 0 {
+				pal[i] = meanPixel(c)
+			}
+		}
+	}
+	return pal
+}
+
+// GeneratePalette generates a palette of at most n colors that represents the colors of the image.
+//
+// Supported palette generation methods: MedianCutPalette, OctreePalette, KMeansPalette.
+//
+// Example:
+//
+//	pal := gift.GeneratePalette(src, 16, gift.KMeansPalette)
+//	g := gift.New(
+//		gift.Dither(pal, gift.FloydSteinbergDither),
+//	)
+//
+func GeneratePalette(img image.Image, n int, method PaletteMethod) color.Palette {
+	if n <= 0 {
+		return nil
+	}
+	samples := paletteSamples(img)
+	var pal []pixel
+	switch method {
+	case OctreePalette:
+		pal = octree(samples, n)
+	case KMeansPalette:
+		pal = kmeans(samples, n)
+	default:
+		pal = medianCut(samples, n)
+	}
+
+	result := make(color.Palette, len(pal))
+	for i, px := range pal {
+		result[i] = color.NRGBA{
+			R: f32u8(px.R * 255),
+			G: f32u8(px.G * 255),
+			B: f32u8(px.B * 255),
+			A: f32u8(px.A * 255),
+		}
+	}
+	return result
+}
+
+// bayer8 is the 8x8 Bayer threshold matrix.
+var bayer8 = [8][8]float32{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// ditherWeight is the share of the quantization error diffused to the neighbour pixel at (dx, dy).
+type ditherWeight struct {
+	dx, dy int
+	weight float32
+}
+
+var ditherWeights = map[DitherMethod][]ditherWeight{
+	FloydSteinbergDither: {
+		{1, 0, 7.0 / 16}, {-1, 1, 3.0 / 16}, {0, 1, 5.0 / 16}, {1, 1, 1.0 / 16},
+	},
+	AtkinsonDither: {
+		{1, 0, 1.0 / 8}, {2, 0, 1.0 / 8}, {-1, 1, 1.0 / 8}, {0, 1, 1.0 / 8}, {1, 1, 1.0 / 8}, {0, 2, 1.0 / 8},
+	},
+}
+
+func clampPixel(px pixel) pixel {
+	return pixel{
+		minf32(maxf32(px.R, 0), 1),
+		minf32(maxf32(px.G, 0), 1),
+		minf32(maxf32(px.B, 0), 1),
+		minf32(maxf32(px.A, 0), 1),
+	}
+}
+
+// ditherImage draws the src image to the dst image using only the colors of the palette.
+// If dst is a paletted image, pal must be the converted palette of dst.
+func ditherImage(dst draw.Image, src image.Image, pal []pixel, method DitherMethod, options *Options) {
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	if srcb.Empty() || len(pal) == 0 {
+		return
+	}
+	pixGetter := newPixelGetter(src)
+	pixSetter := newPixelSetter(dst)
+	set := func(x, y int, k int) {
+		pixSetter.setPixel(dstb.Min.X+x-srcb.Min.X, dstb.Min.Y+y-srcb.Min.Y, pal[k])
+	}
+	if p, ok := dst.(*image.Paletted); ok {
+		set = func(x, y int, k int) {
+			pt := image.Pt(dstb.Min.X+x-srcb.Min.X, dstb.Min.Y+y-srcb.Min.Y)
+			if pt.In(dstb) {
+				p.Pix[p.PixOffset(pt.X, pt.Y)] = uint8(k)
+			}
+		}
+	}
+
+	weights, diffusion := ditherWeights[method]
+	if !diffusion {
+		var spread float32
+		if method == BayerDither {
+			spread = 1
+			if n := math.Cbrt(float64(len(pal))); n > 2 {
+				spread = float32(1 / (n - 1))
+			}
+		}
+		parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+			for y := pmin; y < pmax; y++ {
+				for x := srcb.Min.X; x < srcb.Max.X; x++ {
+					px := pixGetter.getPixel(x, y)
+					if spread != 0 {
+						d := ((bayer8[(y-srcb.Min.Y)&7][(x-srcb.Min.X)&7]+0.5)/64 - 0.5) * spread
+						px.R += d
+						px.G += d
+						px.B += d
+					}
+					set(x, y, getPaletteIndex(pal, clampPixel(px)))
+				}
+			}
+		})
+		return
+	}
+
+	// Error diffusion is sequential: the error buffers hold the current row and the next two rows,
+	// padded by two pixels on both sides.
+	w := srcb.Dx()
+	errs := [3][]pixel{make([]pixel, w+4), make([]pixel, w+4), make([]pixel, w+4)}
+	for y := srcb.Min.Y; y < srcb.Max.Y; y++ {
+		for x := srcb.Min.X; x < srcb.Max.X; x++ {
+			i := x - srcb.Min.X + 2
+			px := pixGetter.getPixel(x, y)
+			e := errs[0][i]
+			px = clampPixel(pixel{px.R + e.R, px.G + e.G, px.B + e.B, px.A + e.A})
+			k := getPaletteIndex(pal, px)
+			set(x, y, k)
+			q := pal[k]
+			e = pixel{px.R - q.R, px.G - q.G, px.B - q.B, px.A - q.A}
+			for _, dw := range weights {
+				t := &errs[dw.dy][i+dw.dx]
+				t.R += e.R * dw.weight
+				t.G += e.G * dw.weight
+				t.B += e.B * dw.weight
+				t.A += e.A * dw.weight
+			}
+		}
+		errs[0], errs[1], errs[2] = errs[1], errs[2], errs[0]
+		for i := range errs[2] {
+			errs[2][i] = pixel{}
+		}
+	}
+}
+
+type ditherFilter struct {
+	palette color.Palette
+	n       int
+	method  DitherMethod
+}
+
+func (p *ditherFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *ditherFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	palette := p.palette
+	if palette == nil {
+		palette = GeneratePalette(src, p.n, MedianCutPalette)
+	}
+	if len(palette) == 0 {
+		copyimage(dst, src, options)
+		return
+	}
+	if pd, ok := dst.(*image.Paletted); ok {
+		// the colors of the palette are mapped to the nearest colors of the destination palette
+		tmp := createTempImage(src.Bounds())
+		ditherImage(tmp, src, convertPalette(palette), p.method, options)
+		ditherImage(dst, tmp, convertPalette(pd.Palette), NoDither, options)
+		return
+	}
+	ditherImage(dst, src, convertPalette(palette), p.method, options)
+}
+
+// Quantize creates a filter that reduces the number of colors of an image to at most n
+// using a palette generated by the median cut algorithm and the specified dithering method.
+// Use GeneratePalette and Dither to choose the palette generation algorithm.
+//
+// Supported dithering methods: NoDither, FloydSteinbergDither, AtkinsonDither, BayerDither.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Quantize(16, gift.FloydSteinbergDither),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Quantize(n int, dither DitherMethod) Filter {
+	return &ditherFilter{
+		n:      n,
+		method: dither,
+	}
+}
+
+// Dither creates a filter that draws an image using only the colors of the given palette
+// and the specified dithering method.
+//
+// Supported dithering methods: NoDither, FloydSteinbergDither, AtkinsonDither, BayerDither.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Dither(palette.WebSafe, gift.AtkinsonDither),
+//	)
+//	dst := image.NewPaletted(g.Bounds(src.Bounds()), palette.WebSafe)
+//	g.Draw(dst, src)
+//
+func Dither(palette color.Palette, method DitherMethod) Filter {
+	return &ditherFilter{
+		palette: palette,
+		method:  method,
+	}
+}
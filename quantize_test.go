@@ -0,0 +1,146 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGeneratePalette(t *testing.T) {
+	colors := []color.NRGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xff, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+		{0x20, 0x20, 0x20, 0xff},
+	}
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetNRGBA(x, y, colors[(x+y)%4])
+		}
+	}
+
+	for _, method := range []PaletteMethod{MedianCutPalette, OctreePalette, KMeansPalette} {
+		pal := GeneratePalette(src, 4, method)
+		if len(pal) != 4 {
+			t.Errorf("GeneratePalette %d: expected 4 colors got %d", method, len(pal))
+			continue
+		}
+		for _, c := range colors {
+			if pal[pal.Index(c)] != c {
+				t.Errorf("GeneratePalette %d: color %v not found in %v", method, c, pal)
+			}
+		}
+		// merging an octree node may leave fewer colors than requested
+		if n := len(GeneratePalette(src, 2, method)); n > 2 || n < 1 || (n != 2 && method != OctreePalette) {
+			t.Errorf("GeneratePalette %d: expected 2 colors got %d", method, n)
+		}
+		if pal := GeneratePalette(src, 16, method); len(pal) != 4 && method != MedianCutPalette {
+			t.Errorf("GeneratePalette %d: expected 4 colors got %d", method, len(pal))
+		}
+	}
+
+	if pal := GeneratePalette(src, 0, MedianCutPalette); pal != nil {
+		t.Errorf("GeneratePalette: expected nil palette got %v", pal)
+	}
+	if pal := GeneratePalette(image.NewNRGBA(image.Rect(0, 0, 0, 0)), 4, OctreePalette); len(pal) != 0 {
+		t.Errorf("GeneratePalette: expected empty palette got %v", pal)
+	}
+
+	dst := image.NewNRGBA(src.Bounds())
+	New(Quantize(4, NoDither)).Draw(dst, src)
+	if !comparePix(dst.Pix, src.Pix) {
+		t.Error("Quantize: colors of a 4-color image changed")
+	}
+}
+
+func TestDither(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 64, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 64; x++ {
+			src.SetGray(x, y, color.Gray{uint8(x * 4)})
+		}
+	}
+	bw := color.Palette{color.Gray{0}, color.Gray{255}}
+
+	mean := func(img *image.Gray, x0, x1 int) float64 {
+		var sum float64
+		for y := 0; y < 16; y++ {
+			for x := x0; x < x1; x++ {
+				sum += float64(img.GrayAt(x, y).Y)
+			}
+		}
+		return sum / float64(16*(x1-x0))
+	}
+
+	for _, method := range []DitherMethod{NoDither, FloydSteinbergDither, AtkinsonDither, BayerDither} {
+		dst := image.NewGray(src.Bounds())
+		New(Dither(bw, method)).Draw(dst, src)
+		for _, v := range dst.Pix {
+			if v != 0 && v != 255 {
+				t.Errorf("Dither %d: unexpected color %d", method, v)
+				break
+			}
+		}
+		if method == NoDither {
+			if dst.GrayAt(31, 0).Y != 0 || dst.GrayAt(33, 0).Y != 255 {
+				t.Errorf("Dither %d: expected threshold at the middle", method)
+			}
+			continue
+		}
+		for x := 8; x < 64; x += 8 {
+			want := mean(src, x-8, x)
+			got := mean(dst, x-8, x)
+			// Atkinson dithering diffuses only 3/4 of the error, losing some contrast
+			if got < want-32 || got > want+32 {
+				t.Errorf("Dither %d: mean of columns %d-%d: expected %.1f got %.1f", method, x-8, x, want, got)
+			}
+		}
+	}
+
+	// paletted destination
+	g := New()
+	g.SetDither(FloydSteinbergDither)
+	pdst := image.NewPaletted(src.Bounds().Add(image.Pt(5, 5)), bw)
+	g.Draw(pdst, src)
+	var ones int
+	for _, v := range pdst.Pix {
+		ones += int(v)
+	}
+	if ones < 400 || ones > 620 {
+		t.Errorf("dithering to paletted image: expected about 504 white pixels got %d", ones)
+	}
+
+	// paletted destination of DrawAt, the area outside the drawn image is not changed
+	for _, op := range []Operator{CopyOperator, OverOperator} {
+		pdst := image.NewPaletted(image.Rect(0, 0, 80, 20), bw)
+		g.DrawAt(pdst, src, image.Pt(10, 2), op)
+		var outside int
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 80; x++ {
+				if !image.Pt(x, y).In(image.Rect(10, 2, 74, 18)) {
+					outside += int(pdst.ColorIndexAt(x, y))
+				}
+			}
+		}
+		if outside != 0 {
+			t.Errorf("DrawAt dithering to paletted image (operator %d): %d white pixels outside", op, outside)
+		}
+		for x := 8; x < 64; x += 8 {
+			var ones int
+			for y := 0; y < 16; y++ {
+				for i := x - 8; i < x; i++ {
+					ones += int(pdst.ColorIndexAt(10+i, 2+y))
+				}
+			}
+			want := mean(src, x-8, x) / 255 * 128
+			if got := float64(ones); got < want-16 || got > want+16 {
+				t.Errorf("DrawAt dithering to paletted image (operator %d): white pixels in columns %d-%d: expected %.1f got %.0f", op, x-8, x, want, got)
+			}
+		}
+	}
+
+	if g := New(Dither(bw, BayerDither)); !g.Bounds(src.Bounds()).Eq(image.Rect(0, 0, 64, 16)) {
+		t.Errorf("Dither: wrong bounds %v", g.Bounds(src.Bounds()))
+	}
+}
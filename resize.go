@@ -1,9 +1,12 @@
 package gift
 
 import (
+	"fmt"
 	"image"
 	"image/draw"
 	"math"
+
+	giftimage "github.com/disintegration/gift/image"
 )
 
 // Resampling is an interpolation algorithm used for image resizing.
@@ -32,6 +35,47 @@ func sinc(x float32) float32 {
 	return float32(math.Sin(math.Pi*float64(x)) / (math.Pi * float64(x)))
 }
 
+func gaussian(x float32) float32 {
+	return float32(math.Exp(-2 * float64(x*x)))
+}
+
+// Window is a window function used to taper the sinc kernel of a windowed-sinc resampling filter.
+type Window int
+
+// Window functions.
+const (
+	// LanczosWindow is the Lanczos window (central lobe of a stretched sinc).
+	LanczosWindow Window = iota
+	// BlackmanWindow is the Blackman window.
+	BlackmanWindow
+	// HannWindow is the Hann (raised cosine) window.
+	HannWindow
+)
+
+func (w Window) String() string {
+	switch w {
+	case BlackmanWindow:
+		return "Blackman"
+	case HannWindow:
+		return "Hann"
+	default:
+		return "Lanczos"
+	}
+}
+
+// window returns the value of the window function w at x for the window of the given half-width.
+func window(w Window, x, halfWidth float32) float32 {
+	t := float64(x / halfWidth)
+	switch w {
+	case BlackmanWindow:
+		return float32(0.42 + 0.5*math.Cos(math.Pi*t) + 0.08*math.Cos(2*math.Pi*t))
+	case HannWindow:
+		return float32(0.5 + 0.5*math.Cos(math.Pi*t))
+	default:
+		return sinc(float32(t))
+	}
+}
+
 type resamp struct {
 	name    string
 	support float32
@@ -65,8 +109,81 @@ var CubicResampling Resampling
 // LanczosResampling is a Lanczos resampling filter (3 lobes).
 var LanczosResampling Resampling
 
+// Lanczos2Resampling is a Lanczos resampling filter (2 lobes).
+var Lanczos2Resampling Resampling
+
+// Lanczos4Resampling is a Lanczos resampling filter (4 lobes).
+var Lanczos4Resampling Resampling
+
+// MitchellResampling is a Mitchell-Netravali bicubic resampling filter (B = 1/3, C = 1/3).
+var MitchellResampling Resampling
+
+// HermiteResampling is a Hermite bicubic resampling filter (B = 0, C = 0).
+var HermiteResampling Resampling
+
+// GaussianResampling is a Gaussian resampling filter (sigma = 0.5).
+var GaussianResampling Resampling
+
+// BlackmanResampling is a Blackman-windowed sinc resampling filter (3 lobes).
+var BlackmanResampling Resampling
+
+// HannResampling is a Hann-windowed sinc resampling filter (3 lobes).
+var HannResampling Resampling
+
+// BCSplineResampling creates a cubic resampling filter from the Mitchell-Netravali family of B,C-splines.
+// Commonly used parameters: B = 0, C = 0.5 (Catmull-Rom, same as CubicResampling),
+// B = 1/3, C = 1/3 (Mitchell-Netravali), B = 1, C = 0 (cubic B-spline, strong smoothing).
+//
+// Example:
+//
+//	// Resize using the "Robidoux" cubic filter.
+//	g := gift.New(
+//		gift.Resize(300, 0, gift.BCSplineResampling(0.3782, 0.3109)),
+//	)
+//
+func BCSplineResampling(b, c float32) Resampling {
+	return resamp{
+		name:    fmt.Sprintf("BCSplineResampling(%g, %g)", b, c),
+		support: 2,
+		kernel: func(x float32) float32 {
+			return bcspline(x, b, c)
+		},
+	}
+}
+
+// WindowedSincResampling creates a windowed-sinc resampling filter with the given number of lobes
+// and the given window function. The lobes parameter must be positive, typically in range (2, 8).
+// WindowedSincResampling(3, LanczosWindow) is the same as LanczosResampling.
+func WindowedSincResampling(lobes int, w Window) Resampling {
+	if lobes < 1 {
+		lobes = 1
+	}
+	support := float32(lobes)
+	return resamp{
+		name:    fmt.Sprintf("WindowedSincResampling(%d, %s)", lobes, w),
+		support: support,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < support {
+				return sinc(x) * window(w, x, support)
+			}
+			return 0
+		},
+	}
+}
+
 func precomputeResamplingWeights(dstSize, srcSize int, resampling Resampling) [][]uweight {
-	du := float32(srcSize) / float32(dstSize)
+	return precomputeRegionWeights(dstSize, srcSize, 0, float32(srcSize), false, resampling)
+}
+
+// precomputeRegionWeights calculates the resampling weights for the region [srcMin, srcMin+srcLen)
+// of a line of srcSize pixels resized to dstSize pixels. Source pixel centers are at u+0.5.
+// If clampEdges is true, the source line is extended by repeating its edge pixels,
+// otherwise the kernel window is truncated at the line ends.
+func precomputeRegionWeights(dstSize, srcSize int, srcMin, srcLen float32, clampEdges bool, resampling Resampling) [][]uweight {
+	du := srcLen / float32(dstSize)
 	scale := du
 	if scale < 1 {
 		scale = 1
@@ -77,15 +194,23 @@ func precomputeResamplingWeights(dstSize, srcSize int, resampling Resampling) []
 	result := make([][]uweight, dstSize)
 
 	for v := 0; v < dstSize; v++ {
-		fU := (float32(v)+0.5)*du - 0.5
+		fU := srcMin + (float32(v)+0.5)*du - 0.5
 
-		startu := int(math.Ceil(float64(fU - ru)))
-		if startu < 0 {
-			startu = 0
+		if resampling.Support() <= 0 {
+			u := minint(maxint(int(math.Floor(float64(fU+0.5))), 0), srcSize-1)
+			result[v] = append(result[v], uweight{u, 1})
+			continue
 		}
+
+		startu := int(math.Ceil(float64(fU - ru)))
 		endu := int(math.Floor(float64(fU + ru)))
-		if endu > srcSize-1 {
-			endu = srcSize - 1
+		if !clampEdges {
+			if startu < 0 {
+				startu = 0
+			}
+			if endu > srcSize-1 {
+				endu = srcSize - 1
+			}
 		}
 
 		sumf := float32(0)
@@ -94,9 +219,20 @@ func precomputeResamplingWeights(dstSize, srcSize int, resampling Resampling) []
 			sumf += w
 			tmp[u-startu] = w
 		}
+		if sumf == 0 {
+			// The kernel window is completely outside the source, use the nearest edge pixel.
+			u := minint(maxint(int(math.Floor(float64(fU+0.5))), 0), srcSize-1)
+			result[v] = append(result[v], uweight{u, 1})
+			continue
+		}
 		for u := startu; u <= endu; u++ {
 			w := tmp[u-startu] / sumf
-			result[v] = append(result[v], uweight{u, w})
+			uc := minint(maxint(u, 0), srcSize-1)
+			if n := len(result[v]); n > 0 && result[v][n-1].u == uc {
+				result[v][n-1].weight += w
+				continue
+			}
+			result[v] = append(result[v], uweight{uc, w})
 		}
 	}
 
@@ -123,38 +259,45 @@ func resizeLine(dstBuf []pixel, srcBuf []pixel, weights [][]uweight) {
 	}
 }
 
-func resizeHorizontal(dst draw.Image, src image.Image, w int, resampling Resampling, options *Options) {
+// resizeRows resizes the src rows in range [ymin, ymax) using the precomputed weights
+// and outputs the result to the dst rows starting from dst.Bounds().Min.Y.
+func resizeRows(dst draw.Image, src image.Image, ymin, ymax int, weights [][]uweight, options *Options) {
+	if resizeRowsFixed(dst, src, ymin, ymax, weights, options) {
+		return
+	}
+
 	srcb := src.Bounds()
 	dstb := dst.Bounds()
 
-	weights := precomputeResamplingWeights(w, srcb.Dx(), resampling)
-
 	pixGetter := newPixelGetter(src)
 	pixSetter := newPixelSetter(dst)
 
-	parallelize(options.Parallelization, srcb.Min.Y, srcb.Max.Y, func(pmin, pmax int) {
+	parallelize(options.Parallelization, ymin, ymax, func(pmin, pmax int) {
 		srcBuf := make([]pixel, srcb.Dx())
-		dstBuf := make([]pixel, w)
+		dstBuf := make([]pixel, len(weights))
 		for srcy := pmin; srcy < pmax; srcy++ {
 			pixGetter.getPixelRow(srcy, &srcBuf)
 			resizeLine(dstBuf, srcBuf, weights)
-			pixSetter.setPixelRow(dstb.Min.Y+srcy-srcb.Min.Y, dstBuf)
+			pixSetter.setPixelRow(dstb.Min.Y+srcy-ymin, dstBuf)
 		}
 	})
 }
 
-func resizeVertical(dst draw.Image, src image.Image, h int, resampling Resampling, options *Options) {
+// resizeColumns resizes all the src columns using the precomputed weights and outputs the result to the dst image.
+func resizeColumns(dst draw.Image, src image.Image, weights [][]uweight, options *Options) {
+	if resizeColumnsFixed(dst, src, weights, options) {
+		return
+	}
+
 	srcb := src.Bounds()
 	dstb := dst.Bounds()
 
-	weights := precomputeResamplingWeights(h, srcb.Dy(), resampling)
-
 	pixGetter := newPixelGetter(src)
 	pixSetter := newPixelSetter(dst)
 
 	parallelize(options.Parallelization, srcb.Min.X, srcb.Max.X, func(pmin, pmax int) {
 		srcBuf := make([]pixel, srcb.Dy())
-		dstBuf := make([]pixel, h)
+		dstBuf := make([]pixel, len(weights))
 		for srcx := pmin; srcx < pmax; srcx++ {
 			pixGetter.getPixelColumn(srcx, &srcBuf)
 			resizeLine(dstBuf, srcBuf, weights)
@@ -163,6 +306,22 @@ func resizeVertical(dst draw.Image, src image.Image, h int, resampling Resamplin
 	})
 }
 
+// resizeHorizontal resizes the src image to the width w. The srcw parameter is the width of the content
+// in src pixels, it is less than the src width if the last column is a partial box-reduced block.
+func resizeHorizontal(dst draw.Image, src image.Image, w int, srcw float32, resampling Resampling, options *Options) {
+	srcb := src.Bounds()
+	weights := precomputeRegionWeights(w, srcb.Dx(), 0, srcw, false, resampling)
+	resizeRows(dst, src, srcb.Min.Y, srcb.Max.Y, weights, options)
+}
+
+// resizeVertical resizes the src image to the height h. The srch parameter is the height of the content
+// in src pixels, it is less than the src height if the last row is a partial box-reduced block.
+func resizeVertical(dst draw.Image, src image.Image, h int, srch float32, resampling Resampling, options *Options) {
+	srcb := src.Bounds()
+	weights := precomputeRegionWeights(h, srcb.Dy(), 0, srch, false, resampling)
+	resizeColumns(dst, src, weights, options)
+}
+
 func resizeNearest(dst draw.Image, src image.Image, w, h int, options *Options) {
 	srcb := src.Bounds()
 	dstb := dst.Bounds()
@@ -186,10 +345,197 @@ func resizeNearest(dst draw.Image, src image.Image, w, h int, options *Options)
 	})
 }
 
+// ResizeOption is an optional parameter of the Resize, ResizeToFit and ResizeToFill filters.
+type ResizeOption func(*resizeOptions)
+
+type resizeOptions struct {
+	linearLight  bool
+	preReduction int
+}
+
+// defaultPreReduction is the default quality of the box pre-reduction (see PreReduction).
+const defaultPreReduction = 4
+
+func newResizeOptions(opts []ResizeOption) resizeOptions {
+	o := resizeOptions{
+		preReduction: defaultPreReduction,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// LinearLight creates a resize option that makes the image resampled in linear light (gamma-correct resizing).
+// The colors are converted from sRGB to linear RGB before resampling and back to sRGB afterwards,
+// using float precision in between. This prevents darkening of high-contrast detail when downscaling.
+func LinearLight() ResizeOption {
+	return func(o *resizeOptions) {
+		o.linearLight = true
+	}
+}
+
+// PreReduction creates a resize option that controls the quality of the fast large-ratio downscaling.
+// When an image is downscaled by a large ratio, it is first reduced by an integer factor using a box filter
+// and then resized to the final size using the specified resampling filter. The quality parameter
+// is the minimum scale ratio that is left for the resampling filter after the pre-reduction.
+// The pre-reduction is only done when the scale ratio is at least 2*quality.
+// Higher values give results closer to the direct resize at the cost of speed, 2 to 4 are reasonable values.
+// The default quality is 4. Use PreReduction(0) to disable the pre-reduction.
+//
+// Example:
+//
+//	// Make a thumbnail of a large image with high quality pre-reduction.
+//	g := gift.New(
+//		gift.Resize(200, 0, gift.LanczosResampling, gift.PreReduction(4)),
+//	)
+//
+func PreReduction(quality int) ResizeOption {
+	return func(o *resizeOptions) {
+		if quality < 0 {
+			quality = 0
+		}
+		o.preReduction = quality
+	}
+}
+
+// preReductionFactor calculates the integer box reduction factor for the given sizes.
+func preReductionFactor(srcSize, dstSize, quality int) int {
+	if quality <= 0 || dstSize <= 0 {
+		return 1
+	}
+	k := srcSize / (dstSize * quality)
+	if k < 2 {
+		return 1
+	}
+	return k
+}
+
+// boxReduce reduces the src image by the integer factors kx and ky averaging each block of pixels
+// and outputs the result to the dst image. Partial blocks at the right and bottom edges are filled
+// by extending the image edges, so all the reduced pixels have the same size.
+func boxReduce(dst draw.Image, src image.Image, kx, ky int, options *Options) {
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+
+	pixGetter := newPixelGetter(src)
+	pixSetter := newPixelSetter(dst)
+	extra := dstb.Dx()*kx - srcb.Dx()
+	n := float32(kx * ky)
+
+	parallelize(options.Parallelization, dstb.Min.Y, dstb.Max.Y, func(pmin, pmax int) {
+		srcBuf := make([]pixel, srcb.Dx())
+		acc := make([]pixel, dstb.Dx())
+		for dsty := pmin; dsty < pmax; dsty++ {
+			for i := range acc {
+				acc[i] = pixel{}
+			}
+			y0 := srcb.Min.Y + (dsty-dstb.Min.Y)*ky
+			for srcy := y0; srcy < y0+ky; srcy++ {
+				pixGetter.getPixelRow(minint(srcy, srcb.Max.Y-1), &srcBuf)
+				for u, c := range srcBuf {
+					i := u / kx
+					acc[i].R += c.R * c.A
+					acc[i].G += c.G * c.A
+					acc[i].B += c.B * c.A
+					acc[i].A += c.A
+				}
+				if extra > 0 {
+					c := srcBuf[len(srcBuf)-1]
+					i := len(acc) - 1
+					e := float32(extra)
+					acc[i].R += c.R * c.A * e
+					acc[i].G += c.G * c.A * e
+					acc[i].B += c.B * c.A * e
+					acc[i].A += c.A * e
+				}
+			}
+			for i := range acc {
+				px := acc[i]
+				if px.A != 0 {
+					px.R /= px.A
+					px.G /= px.A
+					px.B /= px.A
+				}
+				px.A /= n
+				pixSetter.setPixel(dstb.Min.X+i, dsty, px)
+			}
+		}
+	})
+}
+
+// newTempImageLike creates a temp image that keeps the float precision of the img if it has one.
+// Gray images get a 16-bit gray temp image so they can be resized plane by plane.
+func newTempImageLike(img image.Image, r image.Rectangle) draw.Image {
+	switch img.(type) {
+	case *giftimage.F32RGBA, *giftimage.F64RGBA, *giftimage.C64RGBA, *giftimage.C128RGBA:
+		return giftimage.NewF32RGBA(r)
+	case *image.Gray, *image.Gray16:
+		return image.NewGray16(r)
+	}
+	return createTempImage(r)
+}
+
+// resizeImage resizes the src image to w x h and outputs the result to the dst image.
+func resizeImage(dst draw.Image, src image.Image, w, h int, resampling Resampling, opts resizeOptions, options *Options) {
+	if src.Bounds().Dx() == w && src.Bounds().Dy() == h {
+		copyimage(dst, src, options)
+		return
+	}
+
+	if src, ok := src.(*image.YCbCr); ok && resizeYCbCrFixed(dst, src, w, h, resampling, opts, options) {
+		return
+	}
+
+	if resampling.Support() <= 0 {
+		resizeNearest(dst, src, w, h, options)
+		return
+	}
+
+	srcw, srch := src.Bounds().Dx(), src.Bounds().Dy()
+	fw, fh := float32(srcw), float32(srch)
+	kx := preReductionFactor(srcw, w, opts.preReduction)
+	ky := preReductionFactor(srch, h, opts.preReduction)
+	if kx > 1 || ky > 1 {
+		reduced := newTempImageLike(src, image.Rect(0, 0, (srcw+kx-1)/kx, (srch+ky-1)/ky))
+		boxReduce(reduced, src, kx, ky, options)
+		src = reduced
+		// the partial edge blocks cover only a part of a reduced pixel
+		fw, fh = float32(srcw)/float32(kx), float32(srch)/float32(ky)
+	}
+
+	if src.Bounds().Dx() == w && fw == float32(w) {
+		resizeVertical(dst, src, h, fh, resampling, options)
+		return
+	}
+
+	if src.Bounds().Dy() == h && fh == float32(h) {
+		resizeHorizontal(dst, src, w, fw, resampling, options)
+		return
+	}
+
+	tmp := newTempImageLike(src, image.Rect(0, 0, w, src.Bounds().Dy()))
+	resizeHorizontal(tmp, src, w, fw, resampling, options)
+	resizeVertical(dst, tmp, h, fh, resampling, options)
+}
+
+// resizeImageLinear converts the src image to linear light, resizes it to w x h,
+// converts it back to sRGB and outputs the result to the dst image.
+func resizeImageLinear(dst draw.Image, src image.Image, w, h int, resampling Resampling, opts resizeOptions, options *Options) {
+	lin := giftimage.NewF32RGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	ColorspaceSRGBToLinear().Draw(lin, src, options)
+	res := giftimage.NewF32RGBA(image.Rect(0, 0, w, h))
+	resizeImage(res, lin, w, h, resampling, opts, options)
+	ColorspaceLinearToSRGB().Draw(dst, res, options)
+}
+
 type resizeFilter struct {
 	width      int
 	height     int
 	resampling Resampling
+	opts       resizeOptions
 }
 
 func (p *resizeFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
@@ -225,35 +571,19 @@ func (p *resizeFilter) Draw(dst draw.Image, src image.Image, options *Options) {
 		return
 	}
 
-	if src.Bounds().Dx() == w && src.Bounds().Dy() == h {
-		copyimage(dst, src, options)
-		return
-	}
-
-	if p.resampling.Support() <= 0 {
-		resizeNearest(dst, src, w, h, options)
-		return
-	}
-
-	if src.Bounds().Dx() == w {
-		resizeVertical(dst, src, h, p.resampling, options)
+	if p.opts.linearLight && (src.Bounds().Dx() != w || src.Bounds().Dy() != h) {
+		resizeImageLinear(dst, src, w, h, p.resampling, p.opts, options)
 		return
 	}
 
-	if src.Bounds().Dy() == h {
-		resizeHorizontal(dst, src, w, p.resampling, options)
-		return
-	}
-
-	tmp := createTempImage(image.Rect(0, 0, w, src.Bounds().Dy()))
-	resizeHorizontal(tmp, src, w, p.resampling, options)
-	resizeVertical(dst, tmp, h, p.resampling, options)
-	return
+	resizeImage(dst, src, w, h, p.resampling, p.opts, options)
 }
 
 // Resize creates a filter that resizes an image to the specified width and height using the specified resampling.
 // If one of width or height is 0, the image aspect ratio is preserved.
-// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling.
+// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling,
+// Lanczos2Resampling, Lanczos4Resampling, MitchellResampling, HermiteResampling, GaussianResampling, BlackmanResampling,
+// HannResampling, as well as custom filters created with BCSplineResampling and WindowedSincResampling.
 //
 // Example:
 //
@@ -264,11 +594,19 @@ func (p *resizeFilter) Draw(dst draw.Image, src image.Image, options *Options) {
 //	dst := image.NewRGBA(g.Bounds(src.Bounds()))
 //	g.Draw(dst, src)
 //
-func Resize(width, height int, resampling Resampling) Filter {
+// Optional parameters (e.g. LinearLight) can be passed after the resampling parameter:
+//
+//	// Resize the src image to width=300 in linear light.
+//	g := gift.New(
+//		gift.Resize(300, 0, gift.LanczosResampling, gift.LinearLight()),
+//	)
+//
+func Resize(width, height int, resampling Resampling, opts ...ResizeOption) Filter {
 	return &resizeFilter{
 		width:      width,
 		height:     height,
 		resampling: resampling,
+		opts:       newResizeOptions(opts),
 	}
 }
 
@@ -276,6 +614,7 @@ type resizeToFitFilter struct {
 	width      int
 	height     int
 	resampling Resampling
+	opts       resizeOptions
 }
 
 func (p *resizeToFitFilter) Bounds(srcBounds image.Rectangle) image.Rectangle {
@@ -307,17 +646,27 @@ func (p *resizeToFitFilter) Bounds(srcBounds image.Rectangle) image.Rectangle {
 
 func (p *resizeToFitFilter) Draw(dst draw.Image, src image.Image, options *Options) {
 	b := p.Bounds(src.Bounds())
-	Resize(b.Dx(), b.Dy(), p.resampling).Draw(dst, src, options)
+	f := &resizeFilter{
+		width:      b.Dx(),
+		height:     b.Dy(),
+		resampling: p.resampling,
+		opts:       p.opts,
+	}
+	f.Draw(dst, src, options)
 	return
 }
 
 // ResizeToFit creates a filter that resizes an image to fit within the specified dimensions while preserving the aspect ratio.
-// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling.
-func ResizeToFit(width, height int, resampling Resampling) Filter {
+// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling,
+// Lanczos2Resampling, Lanczos4Resampling, MitchellResampling, HermiteResampling, GaussianResampling, BlackmanResampling,
+// HannResampling, as well as custom filters created with BCSplineResampling and WindowedSincResampling.
+// Optional parameters (e.g. LinearLight) can be passed after the resampling parameter.
+func ResizeToFit(width, height int, resampling Resampling, opts ...ResizeOption) Filter {
 	return &resizeToFitFilter{
 		width:      width,
 		height:     height,
 		resampling: resampling,
+		opts:       newResizeOptions(opts),
 	}
 }
 
@@ -326,6 +675,7 @@ type resizeToFillFilter struct {
 	height     int
 	anchor     Anchor
 	resampling Resampling
+	opts       resizeOptions
 }
 
 func (p *resizeToFillFilter) Bounds(srcBounds image.Rectangle) image.Rectangle {
@@ -339,20 +689,14 @@ func (p *resizeToFillFilter) Bounds(srcBounds image.Rectangle) image.Rectangle {
 	return image.Rect(0, 0, w, h)
 }
 
-func (p *resizeToFillFilter) Draw(dst draw.Image, src image.Image, options *Options) {
-	b := p.Bounds(src.Bounds())
-	w, h := b.Dx(), b.Dy()
-
-	if w <= 0 || h <= 0 {
-		return
-	}
-
-	srcw, srch := src.Bounds().Dx(), src.Bounds().Dy()
+// resizedSize returns the size of the image resized to cover the dimensions of the filter before cropping.
+func (p *resizeToFillFilter) resizedSize(srcBounds image.Rectangle) (tmpw, tmph int) {
+	w, h := p.width, p.height
+	srcw, srch := srcBounds.Dx(), srcBounds.Dy()
 
 	wratio := float64(srcw) / float64(w)
 	hratio := float64(srch) / float64(h)
 
-	var tmpw, tmph int
 	if wratio < hratio {
 		tmpw = w
 		tmph = maxint(int(float64(srch)/wratio+0.5), h)
@@ -360,9 +704,26 @@ func (p *resizeToFillFilter) Draw(dst draw.Image, src image.Image, options *Opti
 		tmph = h
 		tmpw = maxint(int(float64(srcw)/hratio+0.5), w)
 	}
+	return
+}
+
+func (p *resizeToFillFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	b := p.Bounds(src.Bounds())
+	w, h := b.Dx(), b.Dy()
+
+	if w <= 0 || h <= 0 {
+		return
+	}
 
-	tmp := createTempImage(image.Rect(0, 0, tmpw, tmph))
-	Resize(tmpw, tmph, p.resampling).Draw(tmp, src, options)
+	tmpw, tmph := p.resizedSize(src.Bounds())
+	tmp := createTempImageFor(image.Rect(0, 0, tmpw, tmph), src, dst)
+	f := &resizeFilter{
+		width:      tmpw,
+		height:     tmph,
+		resampling: p.resampling,
+		opts:       p.opts,
+	}
+	f.Draw(tmp, src, options)
 	CropToSize(w, h, p.anchor).Draw(dst, tmp, options)
 
 	return
@@ -370,13 +731,141 @@ func (p *resizeToFillFilter) Draw(dst draw.Image, src image.Image, options *Opti
 
 // ResizeToFill creates a filter that resizes an image to the smallest possible size that will cover the specified dimensions,
 // then crops the resized image to the specified dimensions using the specified anchor point.
-// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling.
-func ResizeToFill(width, height int, resampling Resampling, anchor Anchor) Filter {
+// Supported resampling parameters: NearestNeighborResampling, BoxResampling, LinearResampling, CubicResampling, LanczosResampling,
+// Lanczos2Resampling, Lanczos4Resampling, MitchellResampling, HermiteResampling, GaussianResampling, BlackmanResampling,
+// HannResampling, as well as custom filters created with BCSplineResampling and WindowedSincResampling.
+// Optional parameters (e.g. LinearLight) can be passed after the anchor parameter.
+func ResizeToFill(width, height int, resampling Resampling, anchor Anchor, opts ...ResizeOption) Filter {
 	return &resizeToFillFilter{
 		width:      width,
 		height:     height,
 		anchor:     anchor,
 		resampling: resampling,
+		opts:       newResizeOptions(opts),
+	}
+}
+
+// RectF is a rectangle with float32 coordinates. It is used to specify a sub-pixel region of an image.
+// The coordinates are in the same space as the image bounds: the pixel (x, y) covers the area
+// from (x, y) to (x+1, y+1).
+type RectF struct {
+	MinX, MinY, MaxX, MaxY float32
+}
+
+// RectFrom converts an image.Rectangle to a RectF.
+func RectFrom(r image.Rectangle) RectF {
+	return RectF{float32(r.Min.X), float32(r.Min.Y), float32(r.Max.X), float32(r.Max.Y)}
+}
+
+// Dx returns the width of the rectangle.
+func (r RectF) Dx() float32 {
+	return r.MaxX - r.MinX
+}
+
+// Dy returns the height of the rectangle.
+func (r RectF) Dy() float32 {
+	return r.MaxY - r.MinY
+}
+
+// Empty reports whether the rectangle contains no area.
+func (r RectF) Empty() bool {
+	return r.MinX >= r.MaxX || r.MinY >= r.MaxY
+}
+
+type resizeRegionFilter struct {
+	rect       RectF
+	width      int
+	height     int
+	resampling Resampling
+	clampEdges bool
+}
+
+func (p *resizeRegionFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	w, h := p.width, p.height
+	rw, rh := p.rect.Dx(), p.rect.Dy()
+
+	if (w == 0 && h == 0) || w < 0 || h < 0 || p.rect.Empty() || srcBounds.Empty() {
+		dstBounds = image.Rect(0, 0, 0, 0)
+	} else if w == 0 {
+		fw := float64(h) * float64(rw) / float64(rh)
+		dstw := int(math.Max(1, math.Floor(fw+0.5)))
+		dstBounds = image.Rect(0, 0, dstw, h)
+	} else if h == 0 {
+		fh := float64(w) * float64(rh) / float64(rw)
+		dsth := int(math.Max(1, math.Floor(fh+0.5)))
+		dstBounds = image.Rect(0, 0, w, dsth)
+	} else {
+		dstBounds = image.Rect(0, 0, w, h)
+	}
+
+	return
+}
+
+func (p *resizeRegionFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	b := p.Bounds(src.Bounds())
+	w, h := b.Dx(), b.Dy()
+
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	srcb := src.Bounds()
+	wx := precomputeRegionWeights(w, srcb.Dx(), p.rect.MinX-float32(srcb.Min.X), p.rect.Dx(), p.clampEdges, p.resampling)
+	wy := precomputeRegionWeights(h, srcb.Dy(), p.rect.MinY-float32(srcb.Min.Y), p.rect.Dy(), p.clampEdges, p.resampling)
+	resizeWeighted(dst, src, wx, wy, options)
+}
+
+// resizeWeighted resizes the src image using the precomputed horizontal and vertical weights
+// and outputs the result to the dst image.
+func resizeWeighted(dst draw.Image, src image.Image, wx, wy [][]uweight, options *Options) {
+	srcb := src.Bounds()
+	w := len(wx)
+
+	// Only the source rows used by the vertical weights are resized horizontally.
+	ymin, ymax := srcb.Dy(), 0
+	for _, ws := range wy {
+		for _, iw := range ws {
+			ymin = minint(ymin, iw.u)
+			ymax = maxint(ymax, iw.u+1)
+		}
+	}
+	for _, ws := range wy {
+		for i := range ws {
+			ws[i].u -= ymin
+		}
+	}
+
+	tmp := newTempImageLike(src, image.Rect(0, 0, w, ymax-ymin))
+	resizeRows(tmp, src, srcb.Min.Y+ymin, srcb.Min.Y+ymax, wx, options)
+	resizeColumns(dst, tmp, wy, options)
+}
+
+// ResizeRegion creates a filter that resamples the specified region of an image to the specified width and height.
+// The rect parameter may have fractional coordinates, which allows smooth zooming and panning and
+// precise extraction of regions of interest. Parts of the region outside of the image are filled
+// by extending the image edges.
+// If one of width or height is 0, the region aspect ratio is preserved.
+//
+// Example:
+//
+//	// Zoom into the region (10.5, 20.25)-(110.5, 95.25) of the src image.
+//	g := gift.New(
+//		gift.ResizeRegion(gift.RectF{10.5, 20.25, 110.5, 95.25}, 400, 300, gift.CubicResampling),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func ResizeRegion(rect RectF, width, height int, resampling Resampling) Filter {
+	return &resizeRegionFilter{
+		rect:       rect,
+		width:      width,
+		height:     height,
+		resampling: resampling,
+		clampEdges: true,
 	}
 }
 
@@ -449,4 +938,97 @@ func init() {
 			return 0
 		},
 	}
+
+	// Lanczos resampling filter (2 lobes).
+	Lanczos2Resampling = resamp{
+		name:    "Lanczos2Resampling",
+		support: 2,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < 2 {
+				return sinc(x) * sinc(x/2)
+			}
+			return 0
+		},
+	}
+
+	// Lanczos resampling filter (4 lobes).
+	Lanczos4Resampling = resamp{
+		name:    "Lanczos4Resampling",
+		support: 4,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < 4 {
+				return sinc(x) * sinc(x/4)
+			}
+			return 0
+		},
+	}
+
+	// Mitchell-Netravali resampling filter.
+	MitchellResampling = resamp{
+		name:    "MitchellResampling",
+		support: 2,
+		kernel: func(x float32) float32 {
+			return bcspline(x, 1.0/3, 1.0/3)
+		},
+	}
+
+	// Hermite resampling filter.
+	HermiteResampling = resamp{
+		name:    "HermiteResampling",
+		support: 1,
+		kernel: func(x float32) float32 {
+			return bcspline(x, 0, 0)
+		},
+	}
+
+	// Gaussian resampling filter.
+	GaussianResampling = resamp{
+		name:    "GaussianResampling",
+		support: 2,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < 2 {
+				return gaussian(x)
+			}
+			return 0
+		},
+	}
+
+	// Blackman-windowed sinc resampling filter (3 lobes).
+	BlackmanResampling = resamp{
+		name:    "BlackmanResampling",
+		support: 3,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < 3 {
+				return sinc(x) * window(BlackmanWindow, x, 3)
+			}
+			return 0
+		},
+	}
+
+	// Hann-windowed sinc resampling filter (3 lobes).
+	HannResampling = resamp{
+		name:    "HannResampling",
+		support: 3,
+		kernel: func(x float32) float32 {
+			if x < 0 {
+				x = -x
+			}
+			if x < 3 {
+				return sinc(x) * window(HannWindow, x, 3)
+			}
+			return 0
+		},
+	}
 }
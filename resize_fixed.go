@@ -0,0 +1,451 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Fixed-point resizing of 8-bit and 16-bit images.
+//
+// Color lines are resampled as alpha-weighted values: for every pixel the line buffer holds
+// R*A, G*A, B*A and A, where all the channels are scaled to the range [0, 65535].
+// Plane lines (gray images and YCbCr planes) hold a single 16-bit value per pixel.
+// The resampling weights are scaled to fixedOne and the sums are accumulated in int64.
+// Since the absolute values of normalized weights sum up to about 1, the sums stay below 2^58.
+
+const (
+	fixedShift = 24
+	fixedOne   = 1 << fixedShift
+)
+
+// fixedPixel holds the alpha-weighted channels of a pixel (R*A, G*A, B*A, A) or,
+// for plane lines, a single value in the R field.
+type fixedPixel struct {
+	r, g, b, a int64
+}
+
+// fixedWeightsLine is the run of fixed-point weights of the source pixels start, start+1, ... used by a destination pixel.
+type fixedWeightsLine struct {
+	start   int
+	weights []int64
+}
+
+// fixedWeights converts the resampling weights to fixed-point weights that sum up to fixedOne exactly.
+// The source pixels of each destination pixel must be consecutive.
+func fixedWeights(weights [][]uweight) []fixedWeightsLine {
+	result := make([]fixedWeightsLine, len(weights))
+	for v, ws := range weights {
+		if len(ws) == 0 {
+			continue
+		}
+		line := fixedWeightsLine{
+			start:   ws[0].u,
+			weights: make([]int64, ws[len(ws)-1].u-ws[0].u+1),
+		}
+		var sum int64
+		imax := 0
+		for _, iw := range ws {
+			i := iw.u - line.start
+			line.weights[i] += int64(math.Floor(float64(iw.weight)*fixedOne + 0.5))
+		}
+		for i, w := range line.weights {
+			sum += w
+			if w > line.weights[imax] {
+				imax = i
+			}
+		}
+		line.weights[imax] += fixedOne - sum
+		result[v] = line
+	}
+	return result
+}
+
+type fixedFormat int
+
+const (
+	ffNone fixedFormat = iota
+	ffNRGBA
+	ffRGBA
+	ffNRGBA64
+	ffGray
+	ffGray16
+)
+
+// fixedImage is a view of an image supported by the fixed-point resizing.
+type fixedImage struct {
+	format fixedFormat
+	pix    []uint8
+	stride int
+	rect   image.Rectangle
+}
+
+func newFixedImage(img image.Image) (fixedImage, bool) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		return fixedImage{ffNRGBA, img.Pix, img.Stride, img.Rect}, true
+	case *image.RGBA:
+		return fixedImage{ffRGBA, img.Pix, img.Stride, img.Rect}, true
+	case *image.NRGBA64:
+		return fixedImage{ffNRGBA64, img.Pix, img.Stride, img.Rect}, true
+	case *image.Gray:
+		return fixedImage{ffGray, img.Pix, img.Stride, img.Rect}, true
+	case *image.Gray16:
+		return fixedImage{ffGray16, img.Pix, img.Stride, img.Rect}, true
+	}
+	return fixedImage{}, false
+}
+
+// isPlane reports whether the image is stored as a single channel plane.
+func (p *fixedImage) isPlane() bool {
+	return p.format == ffGray || p.format == ffGray16
+}
+
+func (p *fixedImage) pixSize() int {
+	switch p.format {
+	case ffNRGBA, ffRGBA:
+		return 4
+	case ffNRGBA64:
+		return 8
+	case ffGray:
+		return 1
+	case ffGray16:
+		return 2
+	}
+	return 0
+}
+
+func (p *fixedImage) offset(x, y int) int {
+	return (y-p.rect.Min.Y)*p.stride + (x-p.rect.Min.X)*p.pixSize()
+}
+
+// readLine reads n pixels starting from the Pix offset off, moving step bytes between the pixels.
+// It reports whether all the pixels of the line are opaque.
+func (p *fixedImage) readLine(buf []fixedPixel, off, step, n int) (opaque bool) {
+	pix := p.pix
+	buf = buf[:n]
+	amin := int64(65535)
+	switch p.format {
+	case ffNRGBA:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+4 : j+4]
+			a := int64(s[3]) * 257
+			buf[i] = fixedPixel{int64(s[0]) * 257 * a, int64(s[1]) * 257 * a, int64(s[2]) * 257 * a, a}
+			if a < amin {
+				amin = a
+			}
+		}
+	case ffRGBA:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+4 : j+4]
+			a := int64(s[3]) * 257
+			buf[i] = fixedPixel{int64(s[0]) * 257 * 65535, int64(s[1]) * 257 * 65535, int64(s[2]) * 257 * 65535, a}
+			if a < amin {
+				amin = a
+			}
+		}
+	case ffNRGBA64:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+8 : j+8]
+			a := int64(s[6])<<8 | int64(s[7])
+			buf[i] = fixedPixel{(int64(s[0])<<8 | int64(s[1])) * a, (int64(s[2])<<8 | int64(s[3])) * a, (int64(s[4])<<8 | int64(s[5])) * a, a}
+			if a < amin {
+				amin = a
+			}
+		}
+	case ffGray:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			buf[i].r = int64(pix[j]) * 257
+		}
+	case ffGray16:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			buf[i].r = int64(pix[j+0])<<8 | int64(pix[j+1])
+		}
+	}
+	return amin == 65535
+}
+
+// divRound returns x / y rounded to the nearest integer. The divisor y must be positive.
+// Negative values of x give 0.
+func divRound(x, y int64) int64 {
+	if x <= 0 {
+		return 0
+	}
+	return (x + y/2) / y
+}
+
+func clampu8(x int64) uint8 {
+	if x > 255 {
+		return 255
+	}
+	return uint8(x)
+}
+
+func clampu16(x int64) uint16 {
+	if x > 65535 {
+		return 65535
+	}
+	return uint16(x)
+}
+
+// fixedOpaque is the accumulated alpha of opaque pixels.
+const fixedOpaque = 65535 * fixedOne
+
+// writeLine writes n pixels of the accumulated sums starting from the Pix offset off, moving step bytes between the pixels.
+// Opaque pixels are handled separately, as division by a constant is much faster.
+func (p *fixedImage) writeLine(acc []fixedPixel, off, step, n int) {
+	pix := p.pix
+	acc = acc[:n]
+	switch p.format {
+	case ffNRGBA:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+4 : j+4]
+			c := acc[i]
+			switch {
+			case c.a == fixedOpaque:
+				s[0] = clampu8(divRound(c.r, fixedOpaque*257))
+				s[1] = clampu8(divRound(c.g, fixedOpaque*257))
+				s[2] = clampu8(divRound(c.b, fixedOpaque*257))
+				s[3] = 255
+			case c.a <= 0:
+				s[0], s[1], s[2], s[3] = 0, 0, 0, 0
+			default:
+				s[0] = clampu8(divRound(c.r, c.a*257))
+				s[1] = clampu8(divRound(c.g, c.a*257))
+				s[2] = clampu8(divRound(c.b, c.a*257))
+				s[3] = clampu8(divRound(c.a, fixedOne*257))
+			}
+		}
+	case ffRGBA:
+		const q = fixedOne * 65535 * 257
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+4 : j+4]
+			c := acc[i]
+			if c.a <= 0 {
+				s[0], s[1], s[2], s[3] = 0, 0, 0, 0
+				continue
+			}
+			s[0] = clampu8(divRound(c.r, q))
+			s[1] = clampu8(divRound(c.g, q))
+			s[2] = clampu8(divRound(c.b, q))
+			s[3] = clampu8(divRound(c.a, fixedOne*257))
+		}
+	case ffNRGBA64:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			s := pix[j : j+8 : j+8]
+			c := acc[i]
+			var r16, g16, b16, a16 uint16
+			switch {
+			case c.a == fixedOpaque:
+				r16 = clampu16(divRound(c.r, fixedOpaque))
+				g16 = clampu16(divRound(c.g, fixedOpaque))
+				b16 = clampu16(divRound(c.b, fixedOpaque))
+				a16 = 65535
+			case c.a > 0:
+				r16 = clampu16(divRound(c.r, c.a))
+				g16 = clampu16(divRound(c.g, c.a))
+				b16 = clampu16(divRound(c.b, c.a))
+				a16 = clampu16(divRound(c.a, fixedOne))
+			}
+			s[0] = uint8(r16 >> 8)
+			s[1] = uint8(r16 & 0xff)
+			s[2] = uint8(g16 >> 8)
+			s[3] = uint8(g16 & 0xff)
+			s[4] = uint8(b16 >> 8)
+			s[5] = uint8(b16 & 0xff)
+			s[6] = uint8(a16 >> 8)
+			s[7] = uint8(a16 & 0xff)
+		}
+	case ffGray:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			pix[j] = clampu8(divRound(acc[i].r, fixedOne*257))
+		}
+	case ffGray16:
+		for i, j := 0, off; i < n; i, j = i+1, j+step {
+			v := clampu16(divRound(acc[i].r, fixedOne))
+			pix[j+0] = uint8(v >> 8)
+			pix[j+1] = uint8(v & 0xff)
+		}
+	}
+}
+
+// resizeLineFixed resamples a line buffer using the fixed-point weights.
+// Plane lines use a single channel, opaque lines skip the alpha channel.
+func resizeLineFixed(dstBuf, srcBuf []fixedPixel, plane, opaque bool, weights []fixedWeightsLine) {
+	switch {
+	case plane:
+		for v, wl := range weights {
+			var s int64
+			src := srcBuf[wl.start : wl.start+len(wl.weights)]
+			for k, w := range wl.weights {
+				s += src[k].r * w
+			}
+			dstBuf[v].r = s
+		}
+
+	case opaque:
+		for v, wl := range weights {
+			var r, g, b int64
+			src := srcBuf[wl.start : wl.start+len(wl.weights)]
+			for k, w := range wl.weights {
+				c := &src[k]
+				r += c.r * w
+				g += c.g * w
+				b += c.b * w
+			}
+			dstBuf[v] = fixedPixel{r, g, b, fixedOpaque}
+		}
+
+	default:
+		for v, wl := range weights {
+			var r, g, b, a int64
+			src := srcBuf[wl.start : wl.start+len(wl.weights)]
+			for k, w := range wl.weights {
+				c := &src[k]
+				r += c.r * w
+				g += c.g * w
+				b += c.b * w
+				a += c.a * w
+			}
+			dstBuf[v] = fixedPixel{r, g, b, a}
+		}
+	}
+}
+
+// newFixedPair checks whether the src and dst images can be resized using the fixed-point arithmetic.
+func newFixedPair(dst draw.Image, src image.Image) (fdst, fsrc fixedImage, ok bool) {
+	if fsrc, ok = newFixedImage(src); !ok {
+		return
+	}
+	if fdst, ok = newFixedImage(dst); !ok {
+		return
+	}
+	ok = fsrc.isPlane() == fdst.isPlane()
+	return
+}
+
+// resizeRowsFixed is the fixed-point version of resizeRows.
+// It returns false if the image types are not supported.
+func resizeRowsFixed(dst draw.Image, src image.Image, ymin, ymax int, weights [][]uweight, options *Options) bool {
+	fdst, fsrc, ok := newFixedPair(dst, src)
+	if !ok {
+		return false
+	}
+
+	srcb := fsrc.rect
+	dstb := fdst.rect
+	plane := fsrc.isPlane()
+	n := minint(len(weights), dstb.Dx())
+	if n <= 0 {
+		return true
+	}
+	fw := fixedWeights(weights)
+
+	parallelize(options.Parallelization, ymin, ymax, func(pmin, pmax int) {
+		srcBuf := make([]fixedPixel, srcb.Dx())
+		dstBuf := make([]fixedPixel, len(weights))
+		for srcy := pmin; srcy < pmax; srcy++ {
+			dsty := dstb.Min.Y + srcy - ymin
+			if dsty >= dstb.Max.Y {
+				continue
+			}
+			opaque := fsrc.readLine(srcBuf, fsrc.offset(srcb.Min.X, srcy), fsrc.pixSize(), srcb.Dx())
+			resizeLineFixed(dstBuf, srcBuf, plane, opaque, fw)
+			fdst.writeLine(dstBuf, fdst.offset(dstb.Min.X, dsty), fdst.pixSize(), n)
+		}
+	})
+	return true
+}
+
+// resizeColumnsFixed is the fixed-point version of resizeColumns.
+// It returns false if the image types are not supported.
+func resizeColumnsFixed(dst draw.Image, src image.Image, weights [][]uweight, options *Options) bool {
+	fdst, fsrc, ok := newFixedPair(dst, src)
+	if !ok {
+		return false
+	}
+
+	srcb := fsrc.rect
+	dstb := fdst.rect
+	plane := fsrc.isPlane()
+	n := minint(len(weights), dstb.Dy())
+	if n <= 0 {
+		return true
+	}
+	fw := fixedWeights(weights)
+
+	parallelize(options.Parallelization, srcb.Min.X, srcb.Max.X, func(pmin, pmax int) {
+		srcBuf := make([]fixedPixel, srcb.Dy())
+		dstBuf := make([]fixedPixel, len(weights))
+		for srcx := pmin; srcx < pmax; srcx++ {
+			dstx := dstb.Min.X + srcx - srcb.Min.X
+			if dstx >= dstb.Max.X {
+				continue
+			}
+			opaque := fsrc.readLine(srcBuf, fsrc.offset(srcx, srcb.Min.Y), fsrc.stride, srcb.Dy())
+			resizeLineFixed(dstBuf, srcBuf, plane, opaque, fw)
+			fdst.writeLine(dstBuf, fdst.offset(dstx, dstb.Min.Y), fdst.stride, n)
+		}
+	})
+	return true
+}
+
+// resizeYCbCrFixed resizes the planes of the YCbCr image separately and converts
+// the result to the NRGBA or RGBA destination image.
+// It returns false if the destination image type is not supported.
+func resizeYCbCrFixed(dst draw.Image, src *image.YCbCr, w, h int, resampling Resampling, opts resizeOptions, options *Options) bool {
+	var pix []uint8
+	var stride int
+	switch dst := dst.(type) {
+	case *image.NRGBA:
+		pix, stride = dst.Pix, dst.Stride
+	case *image.RGBA:
+		pix, stride = dst.Pix, dst.Stride
+	default:
+		return false
+	}
+
+	py, pcb, pcr := yCbCrPlanes(src)
+	if py.Rect.Empty() || pcb.Rect.Empty() {
+		return false
+	}
+	// the chroma planes are resampled over the exact extent of the image, which may end
+	// in the middle of a chroma sample
+	sx, sy := yCbCrSubsampleFactors(src.SubsampleRatio)
+	r := src.Rect
+	region := RectF{
+		float32(r.Min.X) / float32(sx), float32(r.Min.Y) / float32(sy),
+		float32(r.Max.X) / float32(sx), float32(r.Max.Y) / float32(sy),
+	}
+	planes := [3]*image.Gray{py, pcb, pcr}
+	for i, p := range planes {
+		planes[i] = image.NewGray(image.Rect(0, 0, w, h))
+		if i == 0 || (sx == 1 && sy == 1) {
+			resizeImage(planes[i], p, w, h, resampling, opts, options)
+			continue
+		}
+		f := &resizeRegionFilter{
+			rect:       region,
+			width:      w,
+			height:     h,
+			resampling: resampling,
+		}
+		f.Draw(planes[i], p, options)
+	}
+
+	dstb := dst.Bounds()
+	n := minint(w, dstb.Dx())
+	parallelize(options.Parallelization, 0, minint(h, dstb.Dy()), func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			i := y * stride
+			j := y * w
+			for x := 0; x < n; x++ {
+				r, g, b := color.YCbCrToRGB(planes[0].Pix[j+x], planes[1].Pix[j+x], planes[2].Pix[j+x])
+				d := pix[i+x*4 : i+x*4+4 : i+x*4+4]
+				d[0], d[1], d[2], d[3] = r, g, b, 0xff
+			}
+		}
+	})
+	return true
+}
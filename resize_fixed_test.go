@@ -0,0 +1,288 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestFixedWeights(t *testing.T) {
+	for _, r := range []Resampling{BoxResampling, LinearResampling, CubicResampling, LanczosResampling} {
+		for _, sz := range []struct{ dst, src int }{{10, 100}, {100, 10}, {33, 77}, {1, 5}} {
+			fw := fixedWeights(precomputeResamplingWeights(sz.dst, sz.src, r))
+			for v, ws := range fw {
+				var sum int64
+				for _, w := range ws.weights {
+					sum += w
+				}
+				if sum != fixedOne {
+					t.Errorf("fixedWeights %s %d->%d: weights of %d sum up to %d", r, sz.src, sz.dst, v, sum)
+				}
+			}
+		}
+	}
+}
+
+// genericImage hides the concrete type of an image to force the generic float resizing path.
+type genericImage struct {
+	draw.Image
+}
+
+// genericSrcImage is the same for read-only images.
+type genericSrcImage struct {
+	image.Image
+}
+
+// resizeFixedReference is a straightforward per-pixel implementation of the fixed-point resizing
+// of 8-bit NRGBA and RGBA images to an NRGBA image using a 16-bit intermediate image.
+func resizeFixedReference(src image.Image, w, h int, r Resampling) *image.NRGBA {
+	srcb := src.Bounds()
+	wx := fixedWeights(precomputeResamplingWeights(w, srcb.Dx(), r))
+	wy := fixedWeights(precomputeResamplingWeights(h, srcb.Dy(), r))
+
+	// alpha-weighted 16-bit channels of a pixel
+	read8 := func(x, y int) (ca [3]int64, a int64) {
+		switch img := src.(type) {
+		case *image.NRGBA:
+			c := img.NRGBAAt(x, y)
+			a = int64(c.A) * 257
+			ca = [3]int64{int64(c.R) * 257 * a, int64(c.G) * 257 * a, int64(c.B) * 257 * a}
+		case *image.RGBA:
+			c := img.RGBAAt(x, y)
+			a = int64(c.A) * 257
+			ca = [3]int64{int64(c.R) * 257 * 65535, int64(c.G) * 257 * 65535, int64(c.B) * 257 * 65535}
+		}
+		return
+	}
+
+	tmp := image.NewNRGBA64(image.Rect(0, 0, w, srcb.Dy()))
+	for y := 0; y < srcb.Dy(); y++ {
+		for v := 0; v < w; v++ {
+			var acc [3]int64
+			var acca int64
+			for k, w := range wx[v].weights {
+				ca, a := read8(srcb.Min.X+wx[v].start+k, srcb.Min.Y+y)
+				for c := 0; c < 3; c++ {
+					acc[c] += ca[c] * w
+				}
+				acca += a * w
+			}
+			if acca <= 0 {
+				continue
+			}
+			tmp.SetNRGBA64(v, y, color.NRGBA64{
+				clampu16(divRound(acc[0], acca)),
+				clampu16(divRound(acc[1], acca)),
+				clampu16(divRound(acc[2], acca)),
+				clampu16(divRound(acca, fixedOne)),
+			})
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for v := 0; v < h; v++ {
+			var acc [3]int64
+			var acca int64
+			for k, w := range wy[v].weights {
+				c := tmp.NRGBA64At(x, wy[v].start+k)
+				a := int64(c.A)
+				acc[0] += int64(c.R) * a * w
+				acc[1] += int64(c.G) * a * w
+				acc[2] += int64(c.B) * a * w
+				acca += a * w
+			}
+			if acca <= 0 {
+				continue
+			}
+			dst.SetNRGBA(x, v, color.NRGBA{
+				clampu8(divRound(acc[0], acca*257)),
+				clampu8(divRound(acc[1], acca*257)),
+				clampu8(divRound(acc[2], acca*257)),
+				clampu8(divRound(acca, fixedOne*257)),
+			})
+		}
+	}
+	return dst
+}
+
+func genFixedTestImages(w, h int) []draw.Image {
+	nrgba := image.NewNRGBA(image.Rect(-3, 5, w-3, h+5))
+	rgba := image.NewRGBA(image.Rect(2, -7, w+2, h-7))
+	seed := uint32(7)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1664525 + 1013904223
+			a := uint8(seed >> 24)
+			if x%3 == 0 {
+				a = 0xff
+			}
+			c := color.NRGBA{uint8(x * 255 / w), uint8(seed >> 16), uint8(y * 255 / h), a}
+			nrgba.SetNRGBA(nrgba.Rect.Min.X+x, nrgba.Rect.Min.Y+y, c)
+			rgba.Set(rgba.Rect.Min.X+x, rgba.Rect.Min.Y+y, c)
+		}
+	}
+	return []draw.Image{nrgba, rgba}
+}
+
+func TestResizeFixed(t *testing.T) {
+	rfilters := []Resampling{BoxResampling, LinearResampling, CubicResampling, LanczosResampling, MitchellResampling}
+	sizes := []struct{ w, h int }{{20, 15}, {7, 31}, {61, 5}, {150, 90}}
+
+	for _, src := range genFixedTestImages(53, 37) {
+		for _, r := range rfilters {
+			for _, sz := range sizes {
+				for _, prlz := range []bool{true, false} {
+					g := New(Resize(sz.w, sz.h, r, PreReduction(0)))
+					g.SetParallelization(prlz)
+
+					// bit-exact against the reference implementation
+					want := resizeFixedReference(src, sz.w, sz.h, r)
+					got := image.NewNRGBA(image.Rect(0, 0, sz.w, sz.h))
+					g.Draw(got, src)
+					if !comparePix(want.Pix, got.Pix) {
+						t.Errorf("fixed resize %T %s %dx%d differs from the reference", src, r, sz.w, sz.h)
+					}
+
+					// close to the generic float path
+					float := image.NewNRGBA(image.Rect(0, 0, sz.w, sz.h))
+					g.Draw(genericImage{float}, genericImage{src})
+					for i := 0; i < len(float.Pix); i += 4 {
+						if float.Pix[i+3] < 8 {
+							continue // colors of almost transparent pixels are imprecise
+						}
+						c1 := color.NRGBA{float.Pix[i], float.Pix[i+1], float.Pix[i+2], float.Pix[i+3]}
+						c2 := color.NRGBA{got.Pix[i], got.Pix[i+1], got.Pix[i+2], got.Pix[i+3]}
+						if !compareColorsNRGBA(c1, c2, 1) {
+							t.Errorf("fixed resize %T %s %dx%d: expected %v got %v", src, r, sz.w, sz.h, c1, c2)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// RGBA output and the destination smaller than the result
+	src := genFixedTestImages(40, 30)[0]
+	want := image.NewRGBA(image.Rect(0, 0, 15, 10))
+	Resize(15, 10, CubicResampling).Draw(genericImage{want}, src, nil)
+	got := image.NewRGBA(image.Rect(0, 0, 15, 10))
+	Resize(15, 10, CubicResampling).Draw(got, src, nil)
+	for i := range want.Pix {
+		if d := int(want.Pix[i]) - int(got.Pix[i]); d < -1 || d > 1 {
+			t.Errorf("fixed resize to RGBA: pixel %d: expected %d got %d", i, want.Pix[i], got.Pix[i])
+			break
+		}
+	}
+	small := image.NewNRGBA(image.Rect(0, 0, 5, 5))
+	Resize(15, 10, CubicResampling).Draw(small, src, nil)
+
+	// gray planes
+	gray := image.NewGray(image.Rect(0, 0, 40, 30))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 7)
+	}
+	for _, r := range rfilters {
+		want := image.NewGray(image.Rect(0, 0, 17, 12))
+		Resize(17, 12, r).Draw(genericImage{want}, genericImage{gray}, nil)
+		got := image.NewGray(image.Rect(0, 0, 17, 12))
+		Resize(17, 12, r).Draw(got, gray, nil)
+		for i := range want.Pix {
+			if d := int(want.Pix[i]) - int(got.Pix[i]); d < -1 || d > 1 {
+				t.Errorf("fixed resize gray %s: pixel %d: expected %d got %d", r, i, want.Pix[i], got.Pix[i])
+				break
+			}
+		}
+	}
+}
+
+func benchmarkResize8bit(b *testing.B, generic bool) {
+	src := genFixedTestImages(1600, 1200)[0].(*image.NRGBA)
+	for i := 3; i < len(src.Pix); i += 4 {
+		src.Pix[i] = 0xff
+	}
+	f := Resize(800, 600, LanczosResampling)
+	var s image.Image = src
+	var dst draw.Image = image.NewNRGBA(f.Bounds(src.Bounds()))
+	if generic {
+		s = genericImage{src}
+		dst = genericImage{dst}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Draw(dst, s, nil)
+	}
+}
+
+func BenchmarkResizeNRGBAFixed(b *testing.B) {
+	benchmarkResize8bit(b, false)
+}
+
+func BenchmarkResizeNRGBAFloat(b *testing.B) {
+	benchmarkResize8bit(b, true)
+}
+
+func TestResizeYCbCrFixed(t *testing.T) {
+	ratios := []image.YCbCrSubsampleRatio{
+		image.YCbCrSubsampleRatio444,
+		image.YCbCrSubsampleRatio422,
+		image.YCbCrSubsampleRatio420,
+		image.YCbCrSubsampleRatio440,
+		image.YCbCrSubsampleRatio411,
+		image.YCbCrSubsampleRatio410,
+	}
+	for _, ratio := range ratios {
+		src := image.NewYCbCr(image.Rect(0, 0, 64, 48), ratio)
+		for y := 0; y < 48; y++ {
+			for x := 0; x < 64; x++ {
+				src.Y[src.YOffset(x, y)] = uint8(x*3 + y)
+				src.Cb[src.COffset(x, y)] = uint8(128 + (x-y)/4)
+				src.Cr[src.COffset(x, y)] = uint8(120 + y/4)
+			}
+		}
+		for _, r := range []Resampling{NearestNeighborResampling, LinearResampling, LanczosResampling} {
+			f := Resize(16, 12, r)
+			want := image.NewNRGBA(image.Rect(0, 0, 16, 12))
+			f.Draw(genericImage{want}, genericSrcImage{src}, nil)
+			got := image.NewNRGBA(image.Rect(0, 0, 16, 12))
+			f.Draw(got, src, nil)
+			for i := range want.Pix {
+				if d := int(want.Pix[i]) - int(got.Pix[i]); d < -3 || d > 3 {
+					t.Errorf("fixed resize YCbCr %v %s: pixel %d: expected %d got %d", ratio, r, i, want.Pix[i], got.Pix[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func benchmarkResizeYCbCr(b *testing.B, generic bool) {
+	src := image.NewYCbCr(image.Rect(0, 0, 1600, 1200), image.YCbCrSubsampleRatio420)
+	for i := range src.Y {
+		src.Y[i] = uint8(i * 7)
+	}
+	for i := range src.Cb {
+		src.Cb[i], src.Cr[i] = uint8(i*3), uint8(i*5)
+	}
+	f := Resize(800, 600, LanczosResampling)
+	var s image.Image = src
+	var dst draw.Image = image.NewNRGBA(f.Bounds(src.Bounds()))
+	if generic {
+		s = genericSrcImage{src}
+		dst = genericImage{dst}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Draw(dst, s, nil)
+	}
+}
+
+func BenchmarkResizeYCbCrFixed(b *testing.B) {
+	benchmarkResizeYCbCr(b, false)
+}
+
+func BenchmarkResizeYCbCrFloat(b *testing.B) {
+	benchmarkResizeYCbCr(b, true)
+}
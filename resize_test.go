@@ -2,6 +2,7 @@ package gift
 
 import (
 	"image"
+	"math"
 	"testing"
 )
 
@@ -31,6 +32,15 @@ func TestResize(t *testing.T) {
 		LinearResampling,
 		CubicResampling,
 		LanczosResampling,
+		Lanczos2Resampling,
+		Lanczos4Resampling,
+		MitchellResampling,
+		HermiteResampling,
+		GaussianResampling,
+		BlackmanResampling,
+		HannResampling,
+		BCSplineResampling(1, 0),
+		WindowedSincResampling(5, HannWindow),
 	}
 	for _, prlz := range []bool{true, false} {
 		for _, z := range sz {
@@ -112,6 +122,36 @@ func TestResize(t *testing.T) {
 		t.Errorf("bcspline(-2, ...) != 0")
 	}
 
+	// Custom constructors reproduce the predefined filters
+	for _, x := range []float32{0, 0.25, 0.5, 1, 1.5, 1.99, 2.5, -0.75, -2.5} {
+		if v0, v1 := CubicResampling.Kernel(x), BCSplineResampling(0, 0.5).Kernel(x); v0 != v1 {
+			t.Errorf("BCSplineResampling(0, 0.5) kernel(%v): expected %v got %v", x, v0, v1)
+		}
+		if v0, v1 := LanczosResampling.Kernel(x), WindowedSincResampling(3, LanczosWindow).Kernel(x); math.Abs(float64(v0-v1)) > 1e-6 {
+			t.Errorf("WindowedSincResampling(3, LanczosWindow) kernel(%v): expected %v got %v", x, v0, v1)
+		}
+		if v0, v1 := BlackmanResampling.Kernel(x), WindowedSincResampling(3, BlackmanWindow).Kernel(x); v0 != v1 {
+			t.Errorf("WindowedSincResampling(3, BlackmanWindow) kernel(%v): expected %v got %v", x, v0, v1)
+		}
+	}
+
+	// Kernel values at the center
+	for _, f := range []Resampling{MitchellResampling, HermiteResampling, GaussianResampling, HannResampling, Lanczos2Resampling, Lanczos4Resampling} {
+		if f.Kernel(0) <= f.Kernel(0.5) || f.Kernel(0.5) <= 0 {
+			t.Errorf("filter %s: kernel is not peaked at 0", f)
+		}
+	}
+	if v := MitchellResampling.Kernel(0); math.Abs(float64(v)-8.0/9) > 1e-6 {
+		t.Errorf("MitchellResampling kernel(0): expected %v got %v", 8.0/9, v)
+	}
+	if v := HermiteResampling.Kernel(0.5); v != 0.5 {
+		t.Errorf("HermiteResampling kernel(0.5): expected 0.5 got %v", v)
+	}
+
+	if s := WindowedSincResampling(0, HannWindow).(resamp).String(); s != "WindowedSincResampling(1, Hann)" {
+		t.Errorf("unexpected windowed sinc name %q", s)
+	}
+
 	if (resamp{name: "test"}).String() != "test" {
 		t.Error("resamplingStruct String() fail")
 	}
@@ -409,3 +449,283 @@ func TestResizeToFill(t *testing.T) {
 		}
 	}
 }
+
+func TestResizeLinearLight(t *testing.T) {
+	// Black and white stripes averaged in linear light are lighter than in sRGB.
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	src.Pix = []uint8{
+		0x00, 0xff, 0x00, 0xff,
+		0x00, 0xff, 0x00, 0xff,
+	}
+
+	testData := []struct {
+		desc string
+		f    Filter
+		want uint8
+	}{
+		{"resize (sRGB)", Resize(1, 1, BoxResampling), 0x80},
+		{"resize (linear)", Resize(1, 1, BoxResampling, LinearLight()), 0xbc},
+		{"resize (linear, horizontal)", Resize(1, 2, LinearResampling, LinearLight()), 0xbc},
+		{"resize to fit (linear)", ResizeToFit(1, 1, BoxResampling, LinearLight()), 0xbc},
+		{"resize to fill (linear)", ResizeToFill(1, 1, BoxResampling, CenterAnchor, LinearLight()), 0xbc},
+	}
+
+	for _, d := range testData {
+		dst := image.NewGray(d.f.Bounds(src.Bounds()))
+		d.f.Draw(dst, src, nil)
+		for i, v := range dst.Pix {
+			if v+1 < d.want || v > d.want+1 {
+				t.Errorf("test [%s] failed: pixel %d: expected %#x got %#x", d.desc, i, d.want, v)
+			}
+		}
+	}
+
+	// Same-size resize is an exact copy.
+	f := Resize(4, 2, LanczosResampling, LinearLight())
+	dst := image.NewGray(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+	if !comparePix(dst.Pix, src.Pix) {
+		t.Errorf("expected %v got %v", src.Pix, dst.Pix)
+	}
+
+	// Overshooting kernels must not break the conversion back to sRGB.
+	big := image.NewGray(image.Rect(0, 0, 300, 300))
+	for i := range big.Pix {
+		if (i/3)%2 == 0 {
+			big.Pix[i] = 0xff
+		}
+	}
+	f = Resize(1000, 0, LanczosResampling, LinearLight())
+	dst = image.NewGray(f.Bounds(big.Bounds()))
+	f.Draw(dst, big, nil)
+}
+
+func TestPreReductionFactor(t *testing.T) {
+	testData := []struct {
+		src, dst, quality, want int
+	}{
+		{1000, 100, 3, 3},
+		{1000, 100, 0, 1},
+		{1000, 100, 5, 2},
+		{1000, 100, 6, 1},
+		{100, 100, 3, 1},
+		{100, 200, 3, 1},
+		{12000, 200, 3, 20},
+		{12000, 0, 3, 1},
+	}
+	for _, d := range testData {
+		if k := preReductionFactor(d.src, d.dst, d.quality); k != d.want {
+			t.Errorf("preReductionFactor(%d, %d, %d): expected %d got %d", d.src, d.dst, d.quality, d.want, k)
+		}
+	}
+}
+
+func TestResizePreReductionGeometry(t *testing.T) {
+	// The image sizes are not divisible by the reduction factors,
+	// the position and the size of the white rectangle must be preserved.
+	src := image.NewGray(image.Rect(0, 0, 1001, 997))
+	for y := 200; y < 500; y++ {
+		for x := 300; x < 700; x++ {
+			src.Pix[src.PixOffset(x, y)] = 0xff
+		}
+	}
+
+	// centroid and area of the white rectangle in the dst image
+	measure := func(img *image.Gray) (cx, cy, area float64) {
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				v := float64(img.Pix[img.PixOffset(x, y)]) / 0xff
+				cx += (float64(x) + 0.5) * v
+				cy += (float64(y) + 0.5) * v
+				area += v
+			}
+		}
+		return cx / area, cy / area, area
+	}
+
+	for _, q := range []int{1, 2, 3} {
+		for _, size := range []image.Point{{13, 13}, {100, 100}, {37, 51}} {
+			exact := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+			Resize(size.X, size.Y, LinearResampling, PreReduction(0)).Draw(exact, src, nil)
+			fast := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+			Resize(size.X, size.Y, LinearResampling, PreReduction(q)).Draw(fast, src, nil)
+
+			ex, ey, ea := measure(exact)
+			fx, fy, fa := measure(fast)
+			if math.Abs(ex-fx) > 0.05 || math.Abs(ey-fy) > 0.05 || math.Abs(ea-fa) > 0.02*ea {
+				t.Errorf("pre-reduced resize to %v (quality %d): expected centroid (%.3f, %.3f) area %.3f got (%.3f, %.3f) %.3f",
+					size, q, ex, ey, ea, fx, fy, fa)
+			}
+		}
+	}
+}
+
+func genResizeTestImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1664525 + 1013904223
+			i := img.PixOffset(x, y)
+			img.Pix[i+0] = uint8(x * 255 / w)
+			img.Pix[i+1] = uint8(y * 255 / h)
+			img.Pix[i+2] = uint8(seed >> 24)
+			img.Pix[i+3] = 0xff
+		}
+	}
+	return img
+}
+
+func TestResizePreReduction(t *testing.T) {
+	src := genResizeTestImage(1500, 1000)
+
+	for _, r := range []Resampling{LinearResampling, CubicResampling, LanczosResampling} {
+		exact := image.NewNRGBA(image.Rect(0, 0, 100, 67))
+		Resize(100, 67, r, PreReduction(0)).Draw(exact, src, nil)
+
+		for _, q := range []int{3, defaultPreReduction} {
+			fast := image.NewNRGBA(image.Rect(0, 0, 100, 67))
+			if q == defaultPreReduction {
+				Resize(100, 67, r).Draw(fast, src, nil)
+			} else {
+				Resize(100, 67, r, PreReduction(q)).Draw(fast, src, nil)
+			}
+
+			var sum, max int
+			for i := range exact.Pix {
+				d := int(exact.Pix[i]) - int(fast.Pix[i])
+				if d < 0 {
+					d = -d
+				}
+				sum += d
+				if d > max {
+					max = d
+				}
+			}
+			if mean := float64(sum) / float64(len(exact.Pix)); mean > 0.5 || max > 8 {
+				t.Errorf("pre-reduced resize (%s, quality %d) differs from the exact one: mean %v, max %v", r, q, mean, max)
+			}
+		}
+	}
+
+	// Alpha and partial blocks
+	src2 := image.NewNRGBA(image.Rect(-1, -1, 6, 1))
+	src2.Pix = []uint8{
+		0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x80, 0x00, 0x00, 0xff, 0x80,
+		0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x80, 0x00, 0x00, 0xff, 0x80,
+	}
+	dst2 := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	boxReduce(dst2, src2, 3, 2, &defaultOptions)
+	want2 := []uint8{
+		0x80, 0x80, 0x00, 0xaa,
+		0x00, 0xaa, 0x55, 0x80,
+		0x00, 0x00, 0xff, 0x80,
+	}
+	if !comparePix(dst2.Pix, want2) {
+		t.Errorf("boxReduce: expected %v got %v", want2, dst2.Pix)
+	}
+}
+
+func benchmarkResizeThumbnail(b *testing.B, opts ...ResizeOption) {
+	src := genResizeTestImage(4000, 3000)
+	f := Resize(200, 0, LanczosResampling, opts...)
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Draw(dst, src, nil)
+	}
+}
+
+func BenchmarkResizeThumbnailExact(b *testing.B) {
+	benchmarkResizeThumbnail(b, PreReduction(0))
+}
+
+func BenchmarkResizeThumbnailDefault(b *testing.B) {
+	benchmarkResizeThumbnail(b)
+}
+
+func TestResizeRegion(t *testing.T) {
+	src := image.NewGray(image.Rect(-1, -1, 3, 1))
+	src.Pix = []uint8{
+		0x00, 0x40, 0x80, 0xc0,
+		0x10, 0x50, 0x90, 0xd0,
+	}
+
+	testData := []struct {
+		desc   string
+		f      Filter
+		dstb   image.Rectangle
+		dstPix []uint8
+	}{
+		{
+			"resize region (integer rect, box, 1:1)",
+			ResizeRegion(RectF{0, -1, 2, 1}, 2, 2, BoxResampling),
+			image.Rect(0, 0, 2, 2),
+			[]uint8{
+				0x40, 0x80,
+				0x50, 0x90,
+			},
+		},
+		{
+			"resize region (half pixel shift, linear)",
+			ResizeRegion(RectF{-0.5, -1, 1.5, 0}, 2, 1, LinearResampling),
+			image.Rect(0, 0, 2, 1),
+			[]uint8{
+				0x20, 0x60,
+			},
+		},
+		{
+			"resize region (outside, linear)",
+			ResizeRegion(RectF{-3, -1, -2, 0}, 1, 1, LinearResampling),
+			image.Rect(0, 0, 1, 1),
+			[]uint8{
+				0x00,
+			},
+		},
+		{
+			"resize region (preserve aspect ratio, nearest)",
+			ResizeRegion(RectF{-1, -1, 3, 1}, 0, 1, NearestNeighborResampling),
+			image.Rect(0, 0, 2, 1),
+			[]uint8{
+				0x50, 0xd0,
+			},
+		},
+		{
+			"resize region (empty rect)",
+			ResizeRegion(RectF{1, 1, 1, 2}, 2, 2, LinearResampling),
+			image.Rect(0, 0, 0, 0),
+			[]uint8{},
+		},
+		{
+			"resize region (zero size)",
+			ResizeRegion(RectF{0, 0, 1, 1}, 0, 0, LinearResampling),
+			image.Rect(0, 0, 0, 0),
+			[]uint8{},
+		},
+	}
+
+	for _, d := range testData {
+		dst := image.NewGray(d.f.Bounds(src.Bounds()))
+		d.f.Draw(dst, src, nil)
+		if !checkBoundsAndPix(dst.Bounds(), d.dstb, dst.Pix, d.dstPix) {
+			t.Errorf("test [%s] failed: %#v, %#v", d.desc, dst.Bounds(), dst.Pix)
+		}
+	}
+
+	// The whole image region gives the same result as resize.
+	img := genResizeTestImage(60, 40)
+	for _, rs := range []Resampling{NearestNeighborResampling, BoxResampling} {
+		want := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+		Resize(30, 20, rs).Draw(want, img, nil)
+		got := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+		ResizeRegion(RectFrom(img.Bounds()), 30, 20, rs).Draw(got, img, nil)
+		if !comparePix(want.Pix, got.Pix) {
+			t.Errorf("resize region (%s) differs from resize", rs)
+		}
+	}
+
+	f := RectF{0.5, 1.5, 2, 4}
+	if f.Dx() != 1.5 || f.Dy() != 2.5 || f.Empty() || !(RectF{1, 1, 0, 2}).Empty() {
+		t.Errorf("RectF methods failed: %#v", f)
+	}
+}
@@ -0,0 +1,194 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ThresholdMethod specifies the local statistic used by the AdaptiveThreshold filter.
+type ThresholdMethod int
+
+// Adaptive threshold methods.
+const (
+	// MeanThreshold compares every pixel to the mean of its neighborhood.
+	MeanThreshold ThresholdMethod = iota
+	// GaussianThreshold compares every pixel to the gaussian-weighted mean of its neighborhood.
+	GaussianThreshold
+)
+
+// binarize returns a white pixel if the condition is true and a black pixel otherwise, keeping the alpha.
+func binarize(white bool, a float32) pixel {
+	if white {
+		return pixel{1, 1, 1, a}
+	}
+	return pixel{0, 0, 0, a}
+}
+
+// Threshold creates a filter that produces a black and white image:
+// the pixels with the luminance greater than or equal to the level become white, the others become black.
+// The level parameter must be in range [0, 1]. The alpha channel is preserved.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Threshold(0.5),
+//	)
+//	dst := image.NewGray(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Threshold(level float32) Filter {
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			return binarize(0.299*px.R+0.587*px.G+0.114*px.B >= level, px.A)
+		},
+	}
+}
+
+// Posterize creates a filter that reduces the number of levels of every color channel of an image.
+// The input range of every channel is divided into equal intervals mapped to the output levels
+// evenly distributed between 0 and 1. The levels parameter must be in range [2, 256].
+func Posterize(levels int) Filter {
+	n := float32(minint(maxint(levels, 2), 256))
+	return &colorchanFilter{
+		fn: func(x float32) float32 {
+			return minf32(maxf32(floorf32(x*n), 0), n-1) / (n - 1)
+		},
+		lut: true,
+	}
+}
+
+// otsuLevel returns the threshold level maximizing the between-class variance of the histogram.
+func otsuLevel(hist []int) float32 {
+	bins := len(hist)
+	var total, sum float64
+	for i, n := range hist {
+		total += float64(n)
+		sum += float64(i) * float64(n)
+	}
+
+	best, bestVar := 0, -1.0
+	var w0, sum0 float64
+	for t := 0; t < bins-1; t++ {
+		w0 += float64(hist[t])
+		sum0 += float64(t) * float64(hist[t])
+		w1 := total - w0
+		if w0 == 0 || w1 == 0 {
+			continue
+		}
+		m0, m1 := sum0/w0, (sum-sum0)/w1
+		if v := w0 * w1 * (m0 - m1) * (m0 - m1); v > bestVar {
+			best, bestVar = t, v
+		}
+	}
+	if bestVar < 0 {
+		// a single value: everything becomes white
+		return 0
+	}
+	// the values up to the bin t become black
+	return (float32(best) + 0.5) / float32(bins-1)
+}
+
+type otsuThresholdFilter struct{}
+
+func (p *otsuThresholdFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *otsuThresholdFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	h := computeHistograms(src, histogramBins(src), options)
+	Threshold(otsuLevel(h.ch[histLuminance])).Draw(dst, src, options)
+}
+
+// OtsuThreshold creates a filter that produces a black and white image using the threshold level
+// computed by Otsu's method, that separates the luminance histogram of an image into two classes
+// with the maximum between-class variance.
+func OtsuThreshold() Filter {
+	return &otsuThresholdFilter{}
+}
+
+type adaptiveThresholdFilter struct {
+	blockSize int
+	c         float32
+	method    ThresholdMethod
+}
+
+func (p *adaptiveThresholdFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *adaptiveThresholdFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	w, h := srcb.Dx(), srcb.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	pixels := readPixels(src, w, h, options)
+	lum := image.NewGray16(image.Rect(0, 0, w, h))
+	lumSetter := newPixelSetter(lum)
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				px := pixels[y*w+x]
+				v := 0.299*px.R + 0.587*px.G + 0.114*px.B
+				lumSetter.setPixel(x, y, pixel{v, v, v, 1})
+			}
+		}
+	})
+
+	local := image.NewGray16(lum.Bounds())
+	if p.method == GaussianThreshold {
+		sigma := 0.3*(float32(p.blockSize-1)*0.5-1) + 0.8
+		GaussianBlur(sigma).Draw(local, lum, options)
+	} else {
+		Mean(p.blockSize, false).Draw(local, lum, options)
+	}
+
+	lumGetter := newPixelGetter(lum)
+	localGetter := newPixelGetter(local)
+	pixSetter := newPixelSetter(dst)
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				v := lumGetter.getPixel(x, y).R
+				t := localGetter.getPixel(x, y).R - p.c
+				pixSetter.setPixel(dstb.Min.X+x, dstb.Min.Y+y, binarize(v > t, pixels[y*w+x].A))
+			}
+		}
+	})
+}
+
+// AdaptiveThreshold creates a filter that produces a black and white image comparing the luminance
+// of every pixel to a threshold computed from its neighborhood, which handles the uneven lighting
+// (for example, in photos of documents). The pixels brighter than the local mean minus c become white.
+// The blockSize parameter is the size of the neighborhood, it must be an odd integer greater than 1 (for example, 11 or 31).
+// The c parameter is in range [0, 1], typical values are small (for example, 0.02).
+//
+// Supported methods: MeanThreshold, GaussianThreshold.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.AdaptiveThreshold(15, 0.03, gift.GaussianThreshold),
+//	)
+//	dst := image.NewGray(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func AdaptiveThreshold(blockSize int, c float32, method ThresholdMethod) Filter {
+	return &adaptiveThresholdFilter{
+		blockSize: maxint(blockSize, 3) | 1,
+		c:         c,
+		method:    method,
+	}
+}
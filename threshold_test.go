@@ -0,0 +1,105 @@
+package gift
+
+import (
+	"image"
+	"testing"
+)
+
+func TestThreshold(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	src.Pix = []uint8{
+		0x20, 0x20, 0x20, 0xff,
+		0x7f, 0x7f, 0x7f, 0x80,
+		0x80, 0x80, 0x80, 0xff,
+		0xff, 0x00, 0x00, 0xff,
+	}
+	f := Threshold(0.5)
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+	want := []uint8{
+		0x00, 0x00, 0x00, 0xff,
+		0x00, 0x00, 0x00, 0x80,
+		0xff, 0xff, 0xff, 0xff,
+		0x00, 0x00, 0x00, 0xff,
+	}
+	if !comparePix(dst.Pix, want) {
+		t.Errorf("Threshold failed: expected %#v got %#v", want, dst.Pix)
+	}
+}
+
+func TestPosterize(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 8, 1))
+	copy(src.Pix, []uint8{0x00, 0x3f, 0x40, 0x7f, 0x80, 0xbf, 0xc0, 0xff})
+
+	testData := []struct {
+		levels int
+		want   []uint8
+	}{
+		{1, []uint8{0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}},
+		{2, []uint8{0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}},
+		{4, []uint8{0x00, 0x00, 0x55, 0x55, 0xaa, 0xaa, 0xff, 0xff}},
+		{256, []uint8{0x00, 0x3f, 0x40, 0x7f, 0x80, 0xbf, 0xc0, 0xff}},
+	}
+	for _, d := range testData {
+		f := Posterize(d.levels)
+		dst := image.NewGray(f.Bounds(src.Bounds()))
+		f.Draw(dst, src, nil)
+		if !comparePix(dst.Pix, d.want) {
+			t.Errorf("Posterize(%d) failed: expected %#v got %#v", d.levels, d.want, dst.Pix)
+		}
+	}
+}
+
+func TestOtsuThreshold(t *testing.T) {
+	hist := make([]int, 256)
+	hist[40], hist[50], hist[60] = 10, 20, 10
+	hist[180], hist[200] = 15, 15
+	level := otsuLevel(hist)
+	if level <= 60.0/255 || level >= 180.0/255 {
+		t.Errorf("otsuLevel: expected a level between the modes got %v", level)
+	}
+	if level := otsuLevel([]int{0, 5, 0, 0}); level != 0 {
+		t.Errorf("otsuLevel single value: expected 0 got %v", level)
+	}
+
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	copy(src.Pix, []uint8{0xb0, 0xb8, 0xc0, 0xc8, 0xd0, 0xd8, 0xe0, 0xe8})
+	f := OtsuThreshold()
+	dst := image.NewGray(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+	want := []uint8{0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}
+	if !comparePix(dst.Pix, want) {
+		t.Errorf("OtsuThreshold failed: expected %#v got %#v", want, dst.Pix)
+	}
+}
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// dark strokes on a background with a strong lighting gradient
+	src := image.NewGray(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			v := 60 + x*5
+			if x%8 == 4 {
+				v -= 50
+			}
+			src.Pix[y*src.Stride+x] = uint8(v)
+		}
+	}
+
+	for _, method := range []ThresholdMethod{MeanThreshold, GaussianThreshold} {
+		f := AdaptiveThreshold(7, 0.02, method)
+		dst := image.NewGray(f.Bounds(src.Bounds()))
+		f.Draw(dst, src, nil)
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 40; x++ {
+				want := uint8(0xff)
+				if x%8 == 4 {
+					want = 0
+				}
+				if got := dst.Pix[y*dst.Stride+x]; got != want {
+					t.Fatalf("method %d: pixel (%d, %d): expected %#x got %#x", method, x, y, want, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Exposure creates a filter that changes the exposure of an image by the given number of stops.
+// The colors are multiplied by 2^stops, so the values may exceed 1. Positive stops brighten the image
+// and negative stops darken it. Stops = 0 gives the original image.
+// The filter is meant for the linear RGB colors, for example, the HDR images stored in the floating point
+// images of the giftimage package.
+func Exposure(stops float32) Filter {
+	m := float32(math.Exp2(float64(stops)))
+	return &colorchanFilter{
+		fn: func(x float32) float32 {
+			return x * m
+		},
+		lut: false,
+	}
+}
+
+// linearLuminance returns the Rec. 709 luminance of a linear RGB pixel.
+func linearLuminance(px pixel) float32 {
+	return maxf32(0.2126*px.R+0.7152*px.G+0.0722*px.B, 0)
+}
+
+// scaleLuminance scales the colors of the pixel so that its luminance becomes ld.
+// The result is clamped to the range [0, 1].
+func scaleLuminance(px pixel, l, ld float32) pixel {
+	if l <= 0 {
+		return pixel{0, 0, 0, px.A}
+	}
+	s := ld / l
+	return pixel{
+		minf32(maxf32(px.R*s, 0), 1),
+		minf32(maxf32(px.G*s, 0), 1),
+		minf32(maxf32(px.B*s, 0), 1),
+		px.A,
+	}
+}
+
+// luminanceStats returns the luminances of the pixels, their log-average and their maximum.
+func luminanceStats(pixels []pixel) (lums []float32, avg, peak float64) {
+	const delta = 1e-4
+	lums = make([]float32, len(pixels))
+	var sum float64
+	for i, px := range pixels {
+		l := linearLuminance(px)
+		lums[i] = l
+		sum += math.Log(delta + float64(l))
+		peak = math.Max(peak, float64(l))
+	}
+	avg = math.Exp(sum / float64(len(pixels)))
+	return
+}
+
+// toneMapFilter maps the luminances of an image to the display range.
+// The prepare function receives the luminances of all the pixels and returns the mapping function
+// that gets the index of the pixel and its luminance.
+type toneMapFilter struct {
+	prepare func(lums []float32, w, h int, avg, peak float64) func(i int, l float32) float32
+}
+
+func (p *toneMapFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *toneMapFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	w, h := srcb.Dx(), srcb.Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	pixels := readPixels(src, w, h, options)
+	lums, avg, peak := luminanceStats(pixels)
+	fn := p.prepare(lums, w, h, avg, peak)
+	pixSetter := newPixelSetter(dst)
+
+	parallelize(options.Parallelization, 0, h, func(pmin, pmax int) {
+		for y := pmin; y < pmax; y++ {
+			for x := 0; x < w; x++ {
+				i := y*w + x
+				px := scaleLuminance(pixels[i], lums[i], fn(i, lums[i]))
+				pixSetter.setPixel(dstb.Min.X+x, dstb.Min.Y+y, px)
+			}
+		}
+	})
+}
+
+// ToneMapReinhard creates a filter that maps the colors of an HDR image to the range [0, 1]
+// using the global operator of Reinhard et al. The luminance of the image is scaled so that its
+// log-average becomes the key value (typically 0.18), and then compressed by the formula
+// L(1 + L/white^2) / (1 + L), so the scaled luminance white and above is mapped to 1.
+// If the white parameter is not positive, the maximum scaled luminance of the image is used.
+//
+// The tone mapping operators expect and produce the linear RGB colors;
+// use the ColorspaceLinearToSRGB filter to prepare the result for display.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.ToneMapReinhard(0.18, 0),
+//		gift.ColorspaceLinearToSRGB(),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds())) // src is *giftimage.F32RGBA
+//	g.Draw(dst, src)
+//
+func ToneMapReinhard(key, white float32) Filter {
+	return &toneMapFilter{
+		prepare: func(lums []float32, w, h int, avg, peak float64) func(int, float32) float32 {
+			scale := float32(float64(key) / avg)
+			white2 := white * white
+			if white <= 0 {
+				white2 = float32(peak) * scale * float32(peak) * scale
+			}
+			if white2 <= 0 {
+				white2 = 1
+			}
+			return func(i int, l float32) float32 {
+				ls := l * scale
+				return ls * (1 + ls/white2) / (1 + ls)
+			}
+		},
+	}
+}
+
+// ToneMapReinhardLocal creates a filter that maps the colors of an HDR image to the range [0, 1]
+// using the local (dodging-and-burning) operator of Reinhard et al. The luminance of the image is scaled
+// so that its log-average becomes the key value (typically 0.18). Every pixel is then compressed
+// relative to the average luminance of its largest surrounding area without strong contrast,
+// which preserves the local details better than the global operator.
+func ToneMapReinhardLocal(key float32) Filter {
+	const (
+		scales = 8
+		phi    = 8
+		eps    = 0.05
+	)
+	return &toneMapFilter{
+		prepare: func(lums []float32, w, h int, avg, peak float64) func(int, float32) float32 {
+			scale := float64(key) / avg
+			plane := make([]float64, len(lums))
+			for i, l := range lums {
+				plane[i] = float64(l) * scale
+			}
+			// the plane blurred at the scales s = 1.6^i
+			blurred := make([][]float64, scales+1)
+			for i := range blurred {
+				sigma := 0.5 * math.Pow(1.6, float64(i))
+				blurred[i] = blurPlane(plane, w, h, gaussianBlurKernel1d(float32(sigma)))
+			}
+			return func(i int, l float32) float32 {
+				v1 := blurred[0][i]
+				for j := 0; j < scales; j++ {
+					s := math.Pow(1.6, float64(j))
+					v := (blurred[j][i] - blurred[j+1][i]) / (math.Exp2(phi)*float64(key)/(s*s) + blurred[j][i])
+					if math.Abs(v) >= eps {
+						break
+					}
+					v1 = blurred[j][i]
+				}
+				return float32(plane[i] / (1 + v1))
+			}
+		},
+	}
+}
+
+// ToneMapDrago creates a filter that maps the colors of an HDR image to the range [0, 1]
+// using the adaptive logarithmic operator of Drago et al. The bias parameter in range (0, 1) controls
+// the contrast and the brightness of the result, the recommended value is 0.85 (used if bias is out of range).
+// Lower values give brighter images with less contrast.
+func ToneMapDrago(bias float32) Filter {
+	if bias <= 0 || bias >= 1 {
+		bias = 0.85
+	}
+	exp := math.Log(float64(bias)) / math.Log(0.5)
+	return &toneMapFilter{
+		prepare: func(lums []float32, w, h int, avg, peak float64) func(int, float32) float32 {
+			lmax := peak / avg
+			if lmax <= 0 {
+				return func(int, float32) float32 { return 0 }
+			}
+			norm := 1 / math.Log10(lmax+1)
+			return func(i int, l float32) float32 {
+				lw := float64(l) / avg
+				return float32(norm * math.Log(lw+1) / math.Log(2+8*math.Pow(lw/lmax, exp)))
+			}
+		},
+	}
+}
+
+// ToneMapACES creates a filter that maps the colors of an HDR image to the range [0, 1]
+// using the ACES filmic tone curve (Narkowicz approximation). The curve is applied to every color channel.
+func ToneMapACES() Filter {
+	return &colorchanFilter{
+		fn: func(x float32) float32 {
+			x = maxf32(x, 0)
+			return minf32(x*(2.51*x+0.03)/(x*(2.43*x+0.59)+0.14), 1)
+		},
+		lut: true,
+	}
+}
+
+// hableCurve is the filmic curve of Uncharted 2 by John Hable.
+func hableCurve(x float32) float32 {
+	const (
+		a = 0.15 // shoulder strength
+		b = 0.50 // linear strength
+		c = 0.10 // linear angle
+		d = 0.20 // toe strength
+		e = 0.02 // toe numerator
+		f = 0.30 // toe denominator
+	)
+	return (x*(a*x+c*b)+d*e)/(x*(a*x+b)+d*f) - e/f
+}
+
+// ToneMapHable creates a filter that maps the colors of an HDR image to the range [0, 1]
+// using the Uncharted 2 filmic tone curve by John Hable, with the linear white point 11.2.
+// The curve is applied to every color channel.
+func ToneMapHable() Filter {
+	const (
+		exposureBias = 2
+		white        = 11.2
+	)
+	whiteScale := 1 / hableCurve(white)
+	return &colorchanFilter{
+		fn: func(x float32) float32 {
+			x = maxf32(x, 0)
+			return minf32(hableCurve(x*exposureBias)*whiteScale, 1)
+		},
+		lut: true,
+	}
+}
@@ -0,0 +1,156 @@
+package gift
+
+import (
+	"image"
+	"testing"
+
+	giftimage "github.com/disintegration/gift/image"
+)
+
+// hdrImage returns an image with a horizontal gradient of gray levels from 0 to 64
+// and a colored HDR pixel in every row.
+func hdrImage() *giftimage.F32RGBA {
+	img := giftimage.NewF32RGBA(image.Rect(0, 0, 32, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 32; x++ {
+			v := float32(x) * float32(x) / 16
+			i := img.PixOffset(x, y)
+			copy(img.Pix[i:i+4], []float32{v, v, v, 1})
+		}
+		i := img.PixOffset(y, y)
+		copy(img.Pix[i:i+4], []float32{8, 2, 0.5, 1})
+	}
+	return img
+}
+
+func TestFloatPipeline(t *testing.T) {
+	src := giftimage.NewF32RGBA(image.Rect(0, 0, 320, 320)) // large enough for the lookup tables
+	for i := range src.Pix {
+		src.Pix[i] = 4
+		if i%4 == 3 {
+			src.Pix[i] = 1
+		}
+	}
+
+	testData := []struct {
+		desc string
+		g    *GIFT
+		want float32
+	}{
+		{"exposure chain", New(Exposure(1), Exposure(-2), Exposure(2)), 8},
+		{"blur", New(GaussianBlur(1), GaussianBlur(1)), 4},
+		{"lut filter", New(Gamma(0.5), ColorspaceSRGBToLinear()), float32(srgbToLinear(16))},
+		{"channels", New(ChannelCurves(nil, nil, nil, nil, []CurvePoint{{0, 0}, {1, 1}}), Exposure(1)), 8},
+		{"resize", New(Resize(160, 160, LinearResampling), Exposure(0)), 4},
+	}
+
+	for _, d := range testData {
+		dst := giftimage.NewF32RGBA(d.g.Bounds(src.Bounds()))
+		d.g.Draw(dst, src)
+		for i, v := range dst.Pix {
+			want := d.want
+			if i%4 == 3 {
+				want = 1
+			}
+			if absf32(v-want) > want*1e-3 {
+				t.Errorf("test [%s] failed at index %d: expected %v got %v", d.desc, i, want, v)
+				break
+			}
+		}
+	}
+}
+
+func TestExposure(t *testing.T) {
+	testData := []struct {
+		stops   float32
+		in, out float32
+	}{
+		{0, 0.5, 0.5},
+		{1, 0.5, 1},
+		{3, 0.5, 4},
+		{-1, 2, 1},
+		{-2, 0.5, 0.125},
+	}
+
+	for _, d := range testData {
+		got := Exposure(d.stops).(*colorchanFilter).fn(d.in)
+		if absf32(got-d.out) > 1e-6 {
+			t.Errorf("test [stops %v] failed: expected %v got %v", d.stops, d.out, got)
+		}
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	src := hdrImage()
+
+	testData := []struct {
+		desc   string
+		filter Filter
+	}{
+		{"reinhard", ToneMapReinhard(0.18, 0)},
+		{"reinhard white", ToneMapReinhard(0.18, 1)},
+		{"reinhard local", ToneMapReinhardLocal(0.18)},
+		{"drago", ToneMapDrago(0.85)},
+		{"drago default bias", ToneMapDrago(0)},
+		{"aces", ToneMapACES()},
+		{"hable", ToneMapHable()},
+	}
+
+	for _, d := range testData {
+		g := New(d.filter)
+		dst := giftimage.NewF32RGBA(g.Bounds(src.Bounds()))
+		g.Draw(dst, src)
+
+		for i, v := range dst.Pix {
+			if v < 0 || v > 1 {
+				t.Errorf("test [%s] failed: value %v at index %d out of range [0, 1]", d.desc, v, i)
+				break
+			}
+		}
+
+		// the gray gradient in the last row must stay gray and not decreasing
+		y := 7
+		var prev float32
+		for x := 8; x < 32; x++ {
+			i := dst.PixOffset(x, y)
+			r, gr, b, a := dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]
+			if absf32(r-gr) > 1e-5 || absf32(r-b) > 1e-5 || a != 1 {
+				t.Errorf("test [%s] failed: gray pixel %d changed to (%v, %v, %v, %v)", d.desc, x, r, gr, b, a)
+				break
+			}
+			if r < prev-1e-5 {
+				t.Errorf("test [%s] failed: not monotonic at pixel %d: %v < %v", d.desc, x, r, prev)
+				break
+			}
+			prev = r
+		}
+		if prev < 0.5 {
+			t.Errorf("test [%s] failed: the brightest value %v is too dark", d.desc, prev)
+		}
+
+		// the hue of the colored HDR pixel is kept
+		i := dst.PixOffset(7, 7)
+		if !(dst.Pix[i] >= dst.Pix[i+1] && dst.Pix[i+1] >= dst.Pix[i+2]) {
+			t.Errorf("test [%s] failed: colored pixel changed to %v", d.desc, dst.Pix[i:i+4])
+		}
+	}
+}
+
+func TestToneMapEmpty(t *testing.T) {
+	filters := []Filter{ToneMapReinhard(0.18, 0), ToneMapReinhardLocal(0.18), ToneMapDrago(0.85)}
+	for _, f := range filters {
+		// must not panic
+		g := New(f)
+		g.Draw(giftimage.NewF32RGBA(image.Rect(0, 0, 0, 0)), giftimage.NewF32RGBA(image.Rect(0, 0, 0, 0)))
+
+		src := giftimage.NewF32RGBA(image.Rect(0, 0, 2, 2))
+		dst := giftimage.NewF32RGBA(src.Bounds())
+		g.Draw(dst, src)
+		for i, v := range dst.Pix {
+			if v != 0 {
+				t.Errorf("black image: value %v at index %d", v, i)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package gift
+
+import (
+	"image/color"
+	"sort"
+)
+
+// ColorStop is a color at the given position of a gradient. The position is in range [0, 1].
+type ColorStop struct {
+	Position float32
+	Color    color.Color
+}
+
+// gradientStop is a color stop with the color converted to a pixel.
+type gradientStop struct {
+	pos float32
+	px  pixel
+}
+
+// gradientColor returns the color of the gradient at the position t.
+func gradientColor(stops []gradientStop, t float32) pixel {
+	if t <= stops[0].pos {
+		return stops[0].px
+	}
+	for i := 1; i < len(stops); i++ {
+		s0, s1 := stops[i-1], stops[i]
+		if t > s1.pos {
+			continue
+		}
+		d := s1.pos - s0.pos
+		if d <= 0 {
+			return s1.px
+		}
+		k := (t - s0.pos) / d
+		return pixel{
+			s0.px.R + (s1.px.R-s0.px.R)*k,
+			s0.px.G + (s1.px.G-s0.px.G)*k,
+			s0.px.B + (s1.px.B-s0.px.B)*k,
+			s0.px.A + (s1.px.A-s0.px.A)*k,
+		}
+	}
+	return stops[len(stops)-1].px
+}
+
+// GradientMap creates a filter that maps the luminance of every pixel to the color of a gradient:
+// black gets the color at the position 0 and white gets the color at the position 1.
+// The colors are linearly interpolated between the stops, the alpha of the gradient
+// is multiplied by the alpha of the pixel. An empty list of stops gives the original image.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.GradientMap([]gift.ColorStop{
+//			{0, color.NRGBA{0x20, 0x00, 0x40, 0xff}},
+//			{0.5, color.NRGBA{0xd0, 0x30, 0x60, 0xff}},
+//			{1, color.NRGBA{0xff, 0xf0, 0xa0, 0xff}},
+//		}),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func GradientMap(stops []ColorStop) Filter {
+	if len(stops) == 0 {
+		return &copyimageFilter{}
+	}
+
+	gs := make([]gradientStop, len(stops))
+	for i, s := range stops {
+		gs[i] = gradientStop{
+			pos: minf32(maxf32(s.Position, 0), 1),
+			px:  pixelclr(s.Color),
+		}
+	}
+	sort.SliceStable(gs, func(i, j int) bool { return gs[i].pos < gs[j].pos })
+
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			c := gradientColor(gs, 0.299*px.R+0.587*px.G+0.114*px.B)
+			c.A *= px.A
+			return c
+		},
+	}
+}
+
+// Duotone creates a filter that maps the shadows of an image to the shadow color
+// and the highlights to the highlight color. It is a GradientMap with two stops.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.Duotone(color.NRGBA{0x1a, 0x23, 0x7e, 0xff}, color.NRGBA{0xff, 0xd5, 0x4f, 0xff}),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func Duotone(shadow, highlight color.Color) Filter {
+	return GradientMap([]ColorStop{{0, shadow}, {1, highlight}})
+}
+
+// SplitToning creates a filter that tints the shadows and the highlights of an image with different hues.
+// The hue parameters are the angles on the color wheel, typically in range (0, 360).
+// The balance parameter must be in range (-100, 100): positive values extend the highlight toning
+// to the darker tones, negative values extend the shadow toning to the brighter tones.
+// The tint is strongest in the darkest shadows and the brightest highlights and fades out
+// towards the balance point, the lightness of the pixels is preserved.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.SplitToning(220, 40, 0), // blue shadows, orange highlights
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func SplitToning(shadowHue, highlightHue, balance float32) Filter {
+	// the saturation of the tint at the ends of the tonal range
+	const strength = 0.5
+
+	hs := normalizeHue(shadowHue / 360)
+	hh := normalizeHue(highlightHue / 360)
+	pivot := 0.5 - minf32(maxf32(balance, -100), 100)/200
+
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			_, _, l := convertRGBToHSL(px.R, px.G, px.B)
+			var h, w float32
+			if l < pivot {
+				h, w = hs, (pivot-l)/pivot
+			} else if pivot < 1 {
+				h, w = hh, (l-pivot)/(1-pivot)
+			}
+			w = minf32(maxf32(w, 0), 1) * strength
+			if w == 0 {
+				return px
+			}
+			r, g, b := convertHSLToRGB(h, 1, l)
+			px.R += (r - px.R) * w
+			px.G += (g - px.G) * w
+			px.B += (b - px.B) * w
+			return px
+		},
+	}
+}
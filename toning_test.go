@@ -0,0 +1,119 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGradientMap(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 5, 1))
+	src.Pix = []uint8{
+		0x00, 0x00, 0x00, 0xff,
+		0x40, 0x40, 0x40, 0xff,
+		0x80, 0x80, 0x80, 0x80,
+		0xc0, 0xc0, 0xc0, 0xff,
+		0xff, 0xff, 0xff, 0xff,
+	}
+
+	testData := []struct {
+		desc   string
+		filter Filter
+		dstPix []uint8
+	}{
+		{
+			"gradient map",
+			GradientMap([]ColorStop{
+				{1, color.NRGBA{0xff, 0xff, 0x00, 0xff}},
+				{0, color.NRGBA{0x00, 0x00, 0xff, 0xff}},
+				{0.5, color.NRGBA{0xff, 0x00, 0x00, 0x80}},
+			}),
+			[]uint8{
+				0x00, 0x00, 0xff, 0xff,
+				0x80, 0x00, 0x7f, 0xc0,
+				0xff, 0x01, 0x00, 0x40,
+				0xff, 0x81, 0x00, 0xc0,
+				0xff, 0xff, 0x00, 0xff,
+			},
+		},
+		{
+			"single stop",
+			GradientMap([]ColorStop{{0.3, color.NRGBA{0x10, 0x20, 0x30, 0xff}}}),
+			[]uint8{
+				0x10, 0x20, 0x30, 0xff,
+				0x10, 0x20, 0x30, 0xff,
+				0x10, 0x20, 0x30, 0x80,
+				0x10, 0x20, 0x30, 0xff,
+				0x10, 0x20, 0x30, 0xff,
+			},
+		},
+		{
+			"no stops",
+			GradientMap(nil),
+			src.Pix,
+		},
+		{
+			"duotone",
+			Duotone(color.Black, color.NRGBA{0xff, 0x80, 0x00, 0xff}),
+			[]uint8{
+				0x00, 0x00, 0x00, 0xff,
+				0x40, 0x20, 0x00, 0xff,
+				0x80, 0x40, 0x00, 0x80,
+				0xc0, 0x60, 0x00, 0xff,
+				0xff, 0x80, 0x00, 0xff,
+			},
+		},
+	}
+
+	for _, d := range testData {
+		dst := image.NewNRGBA(d.filter.Bounds(src.Bounds()))
+		d.filter.Draw(dst, src, nil)
+		for i := range d.dstPix {
+			if absf32(float32(dst.Pix[i])-float32(d.dstPix[i])) > 1 {
+				t.Errorf("test [%s] failed: expected %#v got %#v", d.desc, d.dstPix, dst.Pix)
+				break
+			}
+		}
+	}
+}
+
+func TestSplitToning(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 5, 1))
+	copy(src.Pix, []uint8{0x00, 0x40, 0x80, 0xc0, 0xff})
+
+	f := SplitToning(240, 30, 0)
+	dst := image.NewNRGBA(f.Bounds(src.Bounds()))
+	f.Draw(dst, src, nil)
+
+	if c := dst.NRGBAAt(0, 0); c != (color.NRGBA{0, 0, 0, 0xff}) {
+		t.Errorf("black: expected unchanged got %v", c)
+	}
+	if c := dst.NRGBAAt(4, 0); c != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("white: expected unchanged got %v", c)
+	}
+	if c := dst.NRGBAAt(1, 0); !(c.B > c.G && c.G == c.R) {
+		t.Errorf("shadows: expected blue tint got %v", c)
+	}
+	if c := dst.NRGBAAt(3, 0); !(c.R > c.G && c.G > c.B) {
+		t.Errorf("highlights: expected orange tint got %v", c)
+	}
+	if c := dst.NRGBAAt(2, 0); !compareColorsNRGBA(c, color.NRGBA{0x80, 0x80, 0x80, 0xff}, 1) {
+		t.Errorf("midtones: expected unchanged got %v", c)
+	}
+
+	// the balance moves the pivot
+	f = SplitToning(240, 30, 50)
+	f.Draw(dst, src, nil)
+	if c := dst.NRGBAAt(2, 0); !(c.R > c.B) {
+		t.Errorf("balance 50: expected toned midtones got %v", c)
+	}
+
+	// the lightness is preserved
+	for i := 0; i < 5; i++ {
+		c := dst.NRGBAAt(i, 0)
+		_, _, l := convertRGBToHSL(float32(c.R)/0xff, float32(c.G)/0xff, float32(c.B)/0xff)
+		if absf32(l*0xff-float32(src.Pix[i])) > 1 {
+			t.Errorf("lightness: expected %v got %v", src.Pix[i], c)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
+
+	giftimage "github.com/disintegration/gift/image"
 )
 
 // parallelize data processing if 'enabled' is true
@@ -155,6 +157,23 @@ func createTempImage(r image.Rectangle) draw.Image {
 	return image.NewNRGBA64(r) // use 16 bits per channel images internally
 }
 
+// check if image stores floating point values that may be out of range [0, 1]
+func isFloatImage(img image.Image) bool {
+	switch img.(type) {
+	case *giftimage.F32RGBA, *giftimage.F64RGBA, *giftimage.C64RGBA, *giftimage.C128RGBA:
+		return true
+	}
+	return false
+}
+
+// create temp image that keeps the values out of range [0, 1] if src or dst is a floating point image
+func createTempImageFor(r image.Rectangle, src, dst image.Image) draw.Image {
+	if isFloatImage(src) || isFloatImage(dst) {
+		return giftimage.NewF32RGBA(r)
+	}
+	return createTempImage(r)
+}
+
 // check if image is opaque
 func isOpaque(img image.Image) bool {
 	switch img := img.(type) {
@@ -0,0 +1,245 @@
+package gift
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+)
+
+// WhiteBalanceMethod is an algorithm used to estimate the color of the illuminant of an image.
+type WhiteBalanceMethod int
+
+// Automatic white balance methods.
+const (
+	// GrayWorldWhiteBalance assumes that the average color of the image is neutral gray.
+	GrayWorldWhiteBalance WhiteBalanceMethod = iota
+	// WhitePatchWhiteBalance (max-RGB) assumes that the brightest values of the channels belong to a white surface.
+	WhitePatchWhiteBalance
+	// ShadesOfGrayWhiteBalance assumes that the Minkowski p-norm (p = 6) of every channel is the same.
+	// It is a compromise between the gray-world and the white-patch methods.
+	ShadesOfGrayWhiteBalance
+)
+
+// bradford is the Bradford chromatic adaptation matrix and its inverse.
+var (
+	bradford = [9]float64{
+		0.8951, 0.2664, -0.1614,
+		-0.7502, 1.7135, 0.0367,
+		0.0389, -0.0685, 1.0296,
+	}
+	bradfordInv = [9]float64{
+		0.9869929, -0.1470543, 0.1599627,
+		0.4323053, 0.5183603, 0.0492912,
+		-0.0085287, 0.0400428, 0.9684867,
+	}
+)
+
+// planckianXY returns the CIE xy chromaticity of a black body radiator (Kim et al. approximation).
+// The temperature is clamped to the range [1667, 25000].
+func planckianXY(t float64) (x, y float64) {
+	t = math.Min(math.Max(t, 1667), 25000)
+	t2, t3 := t*t, t*t*t
+	if t <= 4000 {
+		x = -0.2661239e9/t3 - 0.2343589e6/t2 + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/t3 + 2.1070379e6/t2 + 0.2226347e3/t + 0.240390
+	}
+	x2, x3 := x*x, x*x*x
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x3 - 1.34811020*x2 + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x3 - 1.37418593*x2 + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x3 - 5.87338670*x2 + 3.75112997*x - 0.37001483
+	}
+	return
+}
+
+// illuminantXYZ returns the white point (Y = 1) of the illuminant with the given color temperature.
+// The tint moves the white point perpendicularly to the Planckian locus in the CIE 1960 uv plane,
+// positive values towards green.
+func illuminantXYZ(temperature, tint float64) (x, y, z float64) {
+	uv := func(x, y float64) (u, v float64) {
+		d := -2*x + 12*y + 3
+		return 4 * x / d, 6 * y / d
+	}
+	u, v := uv(planckianXY(temperature))
+	u0, v0 := uv(planckianXY(temperature - 5))
+	u1, v1 := uv(planckianXY(temperature + 5))
+	du, dv := u1-u0, v1-v0
+	if l := math.Hypot(du, dv); l > 0 {
+		u += tint * 2e-4 * dv / l
+		v -= tint * 2e-4 * du / l
+	}
+	d := 2*u - 8*v + 4
+	cx, cy := 3*u/d, 2*v/d
+	return cx / cy, 1, (1 - cx - cy) / cy
+}
+
+// adaptationMatrix returns the matrix converting the linear RGB colors seen under the src white point
+// to the colors seen under the dst white point using the Bradford transform.
+func adaptationMatrix(srcX, srcY, srcZ, dstX, dstY, dstZ float64) [9]float64 {
+	sl, sm, ss := mulMat3Vec(bradford, srcX, srcY, srcZ)
+	dl, dm, ds := mulMat3Vec(bradford, dstX, dstY, dstZ)
+	scale := [9]float64{
+		dl / sl, 0, 0,
+		0, dm / sm, 0,
+		0, 0, ds / ss,
+	}
+	m := mulMat3(scale, mulMat3(bradford, linearRGBToXYZ))
+	return mulMat3(xyzToLinearRGB, mulMat3(bradfordInv, m))
+}
+
+// The lookup tables converting the sRGB values to linear RGB and back, shared by the white balance filters.
+var (
+	linearLutsOnce      sync.Once
+	linearLutToLinear   []float32
+	linearLutFromLinear []float32
+)
+
+// linearLuts returns the lookup tables converting the sRGB values to linear RGB and back.
+// The tables are built once on the first call.
+func linearLuts() (toLinear, fromLinear []float32) {
+	linearLutsOnce.Do(func() {
+		linearLutToLinear = prepareLut(0xffff+1, func(v float32) float32 {
+			return float32(srgbToLinear(float64(v)))
+		})
+		linearLutFromLinear = prepareLut(0xffff+1, func(v float32) float32 {
+			return float32(linearToSRGB(float64(v)))
+		})
+	})
+	return linearLutToLinear, linearLutFromLinear
+}
+
+// linearMatrixFilter creates a filter that multiplies the colors converted to linear RGB by the matrix.
+func linearMatrixFilter(m [9]float64) Filter {
+	toLinear, fromLinear := linearLuts()
+	conv := func(lut []float32, fn func(float64) float64, v float32) float32 {
+		if v >= 0 && v <= 1 {
+			return getFromLut(lut, v)
+		}
+		return float32(fn(float64(v)))
+	}
+	return &colorFilter{
+		fn: func(px pixel) pixel {
+			r := float64(conv(toLinear, srgbToLinear, px.R))
+			g := float64(conv(toLinear, srgbToLinear, px.G))
+			b := float64(conv(toLinear, srgbToLinear, px.B))
+			r, g, b = mulMat3Vec(m, r, g, b)
+			return pixel{
+				conv(fromLinear, linearToSRGB, float32(r)),
+				conv(fromLinear, linearToSRGB, float32(g)),
+				conv(fromLinear, linearToSRGB, float32(b)),
+				px.A,
+			}
+		},
+	}
+}
+
+// WhiteBalance creates a filter that corrects the white balance of an image taken under the light source
+// with the given color temperature (in kelvins) and tint. The colors are adapted from the light source
+// to the 6500K daylight using the Bradford chromatic adaptation, so temperature = 6500 and tint = 0
+// give the original image.
+// Lower temperatures (for example, 3200 for incandescent light) make the image cooler, higher temperatures make it warmer.
+// The tint compensates the green (positive values) or magenta (negative values) cast of the light source,
+// it is typically in range (-100, 100).
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.WhiteBalance(3200, 10), // photo taken under a tungsten lamp
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func WhiteBalance(temperature, tint float32) Filter {
+	sx, sy, sz := illuminantXYZ(float64(temperature), float64(tint))
+	dx, dy, dz := illuminantXYZ(6500, 0)
+	return linearMatrixFilter(adaptationMatrix(sx, sy, sz, dx, dy, dz))
+}
+
+type autoWhiteBalanceFilter struct {
+	method WhiteBalanceMethod
+}
+
+func (p *autoWhiteBalanceFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *autoWhiteBalanceFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	pixels := readPixels(src, w, h, options)
+	toLinear, _ := linearLuts()
+
+	// estimate the illuminant in linear RGB, the pixels are weighted by their alpha
+	var est [3]float64
+	var wsum float64
+	for _, px := range pixels {
+		if px.A <= 0 {
+			continue
+		}
+		c := [3]float64{
+			float64(getFromLut(toLinear, px.R)),
+			float64(getFromLut(toLinear, px.G)),
+			float64(getFromLut(toLinear, px.B)),
+		}
+		wsum += float64(px.A)
+		for i, v := range c {
+			switch p.method {
+			case WhitePatchWhiteBalance:
+				est[i] = math.Max(est[i], v)
+			case ShadesOfGrayWhiteBalance:
+				est[i] += float64(px.A) * math.Pow(v, 6)
+			default:
+				est[i] += float64(px.A) * v
+			}
+		}
+	}
+	for i := range est {
+		if p.method == ShadesOfGrayWhiteBalance {
+			est[i] = math.Pow(est[i]/wsum, 1.0/6)
+		} else if p.method != WhitePatchWhiteBalance {
+			est[i] /= wsum
+		}
+	}
+
+	if wsum == 0 || est[0] <= 0 || est[1] <= 0 || est[2] <= 0 {
+		copyimage(dst, src, options)
+		return
+	}
+
+	// the luminance of the illuminant is preserved
+	sx, sy, sz := mulMat3Vec(linearRGBToXYZ, est[0], est[1], est[2])
+	dx, dy, dz := mulMat3Vec(linearRGBToXYZ, 1, 1, 1)
+	m := adaptationMatrix(sx/sy, 1, sz/sy, dx/dy, 1, dz/dy)
+	linearMatrixFilter(m).Draw(dst, src, options)
+}
+
+// AutoWhiteBalance creates a filter that estimates the color of the light source of an image
+// and removes the color cast using the Bradford chromatic adaptation. The brightness of the image is preserved.
+//
+// Supported methods: GrayWorldWhiteBalance, WhitePatchWhiteBalance, ShadesOfGrayWhiteBalance.
+//
+// Example:
+//
+//	g := gift.New(
+//		gift.AutoWhiteBalance(gift.ShadesOfGrayWhiteBalance),
+//	)
+//	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+//	g.Draw(dst, src)
+//
+func AutoWhiteBalance(method WhiteBalanceMethod) Filter {
+	return &autoWhiteBalanceFilter{
+		method: method,
+	}
+}
@@ -0,0 +1,102 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestWhiteBalance(t *testing.T) {
+	src := loadImage(t, "testdata/src.png")
+	g := New(WhiteBalance(6500, 0))
+	dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	want := image.NewNRGBA(src.Bounds())
+	New().Draw(want, src)
+	for i := range want.Pix {
+		if absf32(float32(want.Pix[i])-float32(dst.Pix[i])) > 1 {
+			t.Errorf("WhiteBalance(6500, 0) changed the image at index %d: expected %d got %d", i, want.Pix[i], dst.Pix[i])
+			break
+		}
+	}
+
+	gray := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	gray.Pix = []uint8{0x80, 0x80, 0x80, 0xff}
+
+	testData := []struct {
+		desc        string
+		temperature float32
+		tint        float32
+		check       func(c color.NRGBA) bool
+	}{
+		{"tungsten", 3000, 0, func(c color.NRGBA) bool { return c.B > c.G+10 && c.G > c.R+10 }},
+		{"shade", 10000, 0, func(c color.NRGBA) bool { return c.R > c.G && c.G > c.B+5 }},
+		{"green cast", 6500, 50, func(c color.NRGBA) bool { return c.R > c.G+5 && c.B > c.G+5 }},
+		{"magenta cast", 6500, -50, func(c color.NRGBA) bool { return c.G > c.R+5 && c.G > c.B+5 }},
+	}
+
+	for _, d := range testData {
+		g := New(WhiteBalance(d.temperature, d.tint))
+		dst := image.NewNRGBA(g.Bounds(gray.Bounds()))
+		g.Draw(dst, gray)
+		if c := dst.NRGBAAt(0, 0); !d.check(c) {
+			t.Errorf("test [%s] failed: unexpected color %v", d.desc, c)
+		}
+	}
+}
+
+func TestPlanckianXY(t *testing.T) {
+	testData := []struct {
+		temperature float64
+		x, y        float64
+	}{
+		{2856, 0.4476, 0.4074}, // illuminant A
+		{6504, 0.3135, 0.3236},
+	}
+	for _, d := range testData {
+		x, y := planckianXY(d.temperature)
+		if math.Abs(x-d.x) > 0.001 || math.Abs(y-d.y) > 0.001 {
+			t.Errorf("planckianXY(%v): expected (%v, %v) got (%v, %v)", d.temperature, d.x, d.y, x, y)
+		}
+	}
+}
+
+func TestAutoWhiteBalance(t *testing.T) {
+	// gray surfaces lit by a yellowish light
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	cast := [3]float64{1, 0.85, 0.6}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			v := float64(x*8+y+1) / 64
+			var c [3]uint8
+			for i := range c {
+				c[i] = uint8(linearToSRGB(v*cast[i])*255 + 0.5)
+			}
+			src.SetNRGBA(x, y, color.NRGBA{c[0], c[1], c[2], 0xff})
+		}
+	}
+
+	for _, method := range []WhiteBalanceMethod{GrayWorldWhiteBalance, WhitePatchWhiteBalance, ShadesOfGrayWhiteBalance} {
+		g := New(AutoWhiteBalance(method))
+		dst := image.NewNRGBA(g.Bounds(src.Bounds()))
+		g.Draw(dst, src)
+		for i := 0; i < len(dst.Pix); i += 4 {
+			c := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+			if !compareColorsNRGBA(c, color.NRGBA{c.G, c.G, c.G, 0xff}, 2) {
+				t.Errorf("method %d: pixel %d is not neutral: %v", method, i/4, c)
+				break
+			}
+		}
+	}
+
+	// a neutral image is not changed
+	gray := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(gray.Pix, []uint8{0x10, 0x50, 0x90, 0xf0})
+	g := New(AutoWhiteBalance(GrayWorldWhiteBalance))
+	dst := image.NewGray(g.Bounds(gray.Bounds()))
+	g.Draw(dst, gray)
+	if !comparePix(gray.Pix, dst.Pix) {
+		t.Errorf("neutral image changed: expected %v got %v", gray.Pix, dst.Pix)
+	}
+}
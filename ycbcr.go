@@ -0,0 +1,391 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// yCbCrFilter is implemented by the filters that can process the luma and chroma planes
+// of YCbCr images directly.
+type yCbCrFilter interface {
+	Filter
+	// yCbCrRatio returns the subsample ratio of the result, or false if the filter
+	// can't process the planes of an image with the given bounds and subsample ratio.
+	yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool)
+	// planeFilter returns the filter that is applied to a plane subsampled by the factors sx and sy.
+	// srcBounds is the bounds of the source image, dstPlane is the bounds of the resulting plane.
+	planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter
+}
+
+// yCbCrSubsampleFactors returns the horizontal and vertical chroma subsampling factors.
+func yCbCrSubsampleFactors(ratio image.YCbCrSubsampleRatio) (sx, sy int) {
+	switch ratio {
+	case image.YCbCrSubsampleRatio422:
+		return 2, 1
+	case image.YCbCrSubsampleRatio420:
+		return 2, 2
+	case image.YCbCrSubsampleRatio440:
+		return 1, 2
+	case image.YCbCrSubsampleRatio411:
+		return 4, 1
+	case image.YCbCrSubsampleRatio410:
+		return 4, 2
+	}
+	return 1, 1
+}
+
+// yCbCrPlanes returns the luma and chroma planes of the YCbCr image as gray images.
+func yCbCrPlanes(img *image.YCbCr) (y, cb, cr *image.Gray) {
+	r := img.Rect
+	sx, sy := yCbCrSubsampleFactors(img.SubsampleRatio)
+	cr0 := image.Rect(r.Min.X/sx, r.Min.Y/sy, (r.Max.X+sx-1)/sx, (r.Max.Y+sy-1)/sy)
+	y = &image.Gray{Pix: img.Y, Stride: img.YStride, Rect: r}
+	cb = &image.Gray{Pix: img.Cb, Stride: img.CStride, Rect: cr0}
+	cr = &image.Gray{Pix: img.Cr, Stride: img.CStride, Rect: cr0}
+	return
+}
+
+// copyPlane copies the src plane to the dst plane, replicating the edge samples
+// if dst is larger than src.
+func copyPlane(dst, src *image.Gray) {
+	srcb := src.Bounds()
+	dstb := dst.Bounds()
+	if srcb.Empty() {
+		return
+	}
+	for y := 0; y < dstb.Dy(); y++ {
+		srcy := srcb.Min.Y + minint(y, srcb.Dy()-1)
+		i := dst.PixOffset(dstb.Min.X, dstb.Min.Y+y)
+		j := src.PixOffset(srcb.Min.X, srcy)
+		n := copy(dst.Pix[i:i+dstb.Dx()], src.Pix[j:j+srcb.Dx()])
+		for x := n; x < dstb.Dx(); x++ {
+			dst.Pix[i+x] = src.Pix[j+n-1]
+		}
+	}
+}
+
+// drawYCbCrPlanes applies the filter to every plane of the src image.
+func drawYCbCrPlanes(f yCbCrFilter, src *image.YCbCr, ratio image.YCbCrSubsampleRatio, options *Options) *image.YCbCr {
+	dst := image.NewYCbCr(f.Bounds(src.Rect), ratio)
+	if dst.Rect.Empty() {
+		return dst
+	}
+
+	sy0, scb, scr := yCbCrPlanes(src)
+	dy0, dcb, dcr := yCbCrPlanes(dst)
+	srcPlanes := []*image.Gray{sy0, scb, scr}
+	dstPlanes := []*image.Gray{dy0, dcb, dcr}
+	csx, csy := yCbCrSubsampleFactors(src.SubsampleRatio)
+
+	for i := range srcPlanes {
+		sx, sy := 1, 1
+		if i > 0 {
+			sx, sy = csx, csy
+		}
+		pf := f.planeFilter(src.Rect, dstPlanes[i].Rect, sx, sy)
+		b := pf.Bounds(srcPlanes[i].Rect)
+		if b.Dx() == dstPlanes[i].Rect.Dx() && b.Dy() == dstPlanes[i].Rect.Dy() {
+			pf.Draw(dstPlanes[i], srcPlanes[i], options)
+			continue
+		}
+		tmp := image.NewGray(b)
+		pf.Draw(tmp, srcPlanes[i], options)
+		copyPlane(dstPlanes[i], tmp)
+	}
+	return dst
+}
+
+// convertToYCbCr converts the image to a YCbCr image with the given subsample ratio.
+// The chroma samples are averaged over the subsampled blocks. Transparent pixels are composed over black.
+func convertToYCbCr(img image.Image, ratio image.YCbCrSubsampleRatio, options *Options) *image.YCbCr {
+	srcb := img.Bounds()
+	dst := image.NewYCbCr(image.Rect(0, 0, srcb.Dx(), srcb.Dy()), ratio)
+	pixGetter := newPixelGetter(img)
+	sx, sy := yCbCrSubsampleFactors(ratio)
+	ch := (srcb.Dy() + sy - 1) / sy
+
+	parallelize(options.Parallelization, 0, ch, func(pmin, pmax int) {
+		for cy := pmin; cy < pmax; cy++ {
+			for cx := 0; cx < (srcb.Dx()+sx-1)/sx; cx++ {
+				var cbsum, crsum, n int
+				for y := cy * sy; y < minint(cy*sy+sy, srcb.Dy()); y++ {
+					for x := cx * sx; x < minint(cx*sx+sx, srcb.Dx()); x++ {
+						px := pixGetter.getPixel(srcb.Min.X+x, srcb.Min.Y+y)
+						r := f32u8(px.R * px.A * 255)
+						g := f32u8(px.G * px.A * 255)
+						b := f32u8(px.B * px.A * 255)
+						yy, cb, cr := color.RGBToYCbCr(r, g, b)
+						dst.Y[dst.YOffset(x, y)] = yy
+						cbsum += int(cb)
+						crsum += int(cr)
+						n++
+					}
+				}
+				i := dst.COffset(cx*sx, cy*sy)
+				dst.Cb[i] = uint8((cbsum + n/2) / n)
+				dst.Cr[i] = uint8((crsum + n/2) / n)
+			}
+		}
+	})
+	return dst
+}
+
+// ApplyYCbCr applies all the added filters to the src YCbCr image and returns the result as a new YCbCr image.
+//
+// Resize, ResizeToFit, ResizeToFill, Crop, CropToSize, GaussianBlur, flips, transposes and rotations
+// by multiples of 90 degrees process the luma and chroma planes directly, without converting the pixels to RGB.
+// Rotations by 90 degrees swap the 4:2:2 and 4:4:0 subsample ratios.
+// Crops, flips and rotations that don't keep the chroma samples aligned with the blocks of luma samples
+// (for example, a crop at an odd offset of a 4:2:0 image) are processed in RGB.
+// The other filters are applied to the RGB pixels and their results are converted back to YCbCr.
+//
+// Example:
+//
+//	src, err := jpeg.Decode(r) // src is *image.YCbCr
+//	...
+//	g := gift.New(
+//		gift.Resize(320, 0, gift.LanczosResampling),
+//		gift.GaussianBlur(0.5),
+//	)
+//	dst := g.ApplyYCbCr(src.(*image.YCbCr))
+//
+func (g *GIFT) ApplyYCbCr(src *image.YCbCr) *image.YCbCr {
+	cur := src
+	for _, f := range g.Filters {
+		if yf, ok := f.(yCbCrFilter); ok {
+			if ratio, ok := yf.yCbCrRatio(cur.Rect, cur.SubsampleRatio); ok {
+				cur = drawYCbCrPlanes(yf, cur, ratio, &g.Options)
+				continue
+			}
+		}
+		tmp := image.NewNRGBA(f.Bounds(cur.Rect))
+		f.Draw(tmp, cur, &g.Options)
+		cur = convertToYCbCr(tmp, cur.SubsampleRatio, &g.Options)
+	}
+
+	if cur == src {
+		cur = image.NewYCbCr(image.Rect(0, 0, src.Rect.Dx(), src.Rect.Dy()), src.SubsampleRatio)
+		sy0, scb, scr := yCbCrPlanes(src)
+		dy0, dcb, dcr := yCbCrPlanes(cur)
+		copyPlane(dy0, sy0)
+		copyPlane(dcb, scb)
+		copyPlane(dcr, scr)
+	}
+	return cur
+}
+
+// swapYCbCrRatio returns the subsample ratio of a transposed YCbCr image.
+func swapYCbCrRatio(ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	switch ratio {
+	case image.YCbCrSubsampleRatio444, image.YCbCrSubsampleRatio420:
+		return ratio, true
+	case image.YCbCrSubsampleRatio422:
+		return image.YCbCrSubsampleRatio440, true
+	case image.YCbCrSubsampleRatio440:
+		return image.YCbCrSubsampleRatio422, true
+	}
+	return ratio, false
+}
+
+// yCbCrAligned reports whether the point is on a boundary of the chroma blocks of the subsample ratio.
+func yCbCrAligned(pt image.Point, ratio image.YCbCrSubsampleRatio) bool {
+	sx, sy := yCbCrSubsampleFactors(ratio)
+	return pt.X%sx == 0 && pt.Y%sy == 0
+}
+
+// planeRect returns the rectangle of the plane subsampled by the factors sx and sy
+// that covers the given rectangle.
+func planeRect(r image.Rectangle, sx, sy int) image.Rectangle {
+	return image.Rect(r.Min.X/sx, r.Min.Y/sy, (r.Max.X+sx-1)/sx, (r.Max.Y+sy-1)/sy)
+}
+
+// planeResizeFilter resamples a region of a chroma plane directly to the size of the chroma plane
+// of the resized image. Every chroma sample covers a block of sx x sy pixels of the resized luma plane,
+// the partial blocks at the right and bottom edges cover only the pixels inside the image.
+type planeResizeFilter struct {
+	rect       RectF
+	w, h       int
+	sx, sy     int
+	resampling Resampling
+}
+
+// resizePlane creates the filter that resamples the region of the source image, that is resized to w x h,
+// to the plane subsampled by the factors sx and sy. The region is in the source image coordinates.
+func resizePlane(region RectF, w, h, sx, sy int, resampling Resampling) Filter {
+	fsx, fsy := float32(sx), float32(sy)
+	return &planeResizeFilter{
+		rect:       RectF{region.MinX / fsx, region.MinY / fsy, region.MaxX / fsx, region.MaxY / fsy},
+		w:          w,
+		h:          h,
+		sx:         sx,
+		sy:         sy,
+		resampling: resampling,
+	}
+}
+
+func (p *planeResizeFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, (p.w+p.sx-1)/p.sx, (p.h+p.sy-1)/p.sy)
+	return
+}
+
+func (p *planeResizeFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	if p.w <= 0 || p.h <= 0 || srcb.Empty() {
+		return
+	}
+
+	wx := precomputePlaneWeights(p.w, p.sx, srcb.Dx(), p.rect.MinX-float32(srcb.Min.X), p.rect.Dx(), p.resampling)
+	wy := precomputePlaneWeights(p.h, p.sy, srcb.Dy(), p.rect.MinY-float32(srcb.Min.Y), p.rect.Dy(), p.resampling)
+	resizeWeighted(dst, src, wx, wy, options)
+}
+
+// precomputePlaneWeights calculates the weights of the chroma samples of a plane subsampled by the factor sub
+// when the source range (srcMin, srcLen) of the plane is resized to the luma size dstSize.
+// The last sample covers only the rest of the range if dstSize is not divisible by sub.
+func precomputePlaneWeights(dstSize, sub, srcSize int, srcMin, srcLen float32, resampling Resampling) [][]uweight {
+	du := srcLen / float32(dstSize)
+	n := dstSize / sub
+	weights := precomputeRegionWeights(n, srcSize, srcMin, float32(n*sub)*du, false, resampling)
+	if rest := dstSize - n*sub; rest > 0 {
+		last := precomputeRegionWeights(1, srcSize, srcMin+float32(n*sub)*du, float32(rest)*du, false, resampling)
+		weights = append(weights, last...)
+	}
+	return weights
+}
+
+func (p *transformFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	// the flipped axes must consist of whole chroma blocks
+	sx, sy := yCbCrSubsampleFactors(ratio)
+	if !yCbCrAligned(srcBounds.Min, ratio) {
+		return ratio, false
+	}
+	if p.tt != ttTranspose && (srcBounds.Dx()%sx != 0 || srcBounds.Dy()%sy != 0) {
+		return ratio, false
+	}
+	if p.tt == ttRotate90 || p.tt == ttRotate270 || p.tt == ttTranspose || p.tt == ttTransverse {
+		return swapYCbCrRatio(ratio)
+	}
+	return ratio, true
+}
+
+func (p *transformFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	return p
+}
+
+func (p *cropFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	return ratio, yCbCrAligned(srcBounds.Intersect(p.rect).Min, ratio)
+}
+
+func (p *cropFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	return Crop(planeRect(srcBounds.Intersect(p.rect), sx, sy))
+}
+
+func (p *cropToSizeFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	if p.w <= 0 || p.h <= 0 {
+		return ratio, true
+	}
+	pt := anchorPt(srcBounds, p.w, p.h, p.anchor)
+	r := image.Rect(0, 0, p.w, p.h).Add(pt)
+	return ratio, yCbCrAligned(srcBounds.Intersect(r).Min, ratio)
+}
+
+func (p *cropToSizeFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	if p.w <= 0 || p.h <= 0 {
+		return p
+	}
+	pt := anchorPt(srcBounds, p.w, p.h, p.anchor)
+	r := image.Rect(0, 0, p.w, p.h).Add(pt)
+	return Crop(planeRect(srcBounds.Intersect(r), sx, sy))
+}
+
+func (p *resizeFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	return ratio, !p.opts.linearLight
+}
+
+func (p *resizeFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	if sx == 1 && sy == 1 {
+		return p
+	}
+	b := p.Bounds(srcBounds)
+	return resizePlane(RectFrom(srcBounds), b.Dx(), b.Dy(), sx, sy, p.resampling)
+}
+
+func (p *resizeToFitFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	return ratio, !p.opts.linearLight
+}
+
+func (p *resizeToFitFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	if sx == 1 && sy == 1 {
+		return p
+	}
+	b := p.Bounds(srcBounds)
+	return resizePlane(RectFrom(srcBounds), b.Dx(), b.Dy(), sx, sy, p.resampling)
+}
+
+func (p *resizeToFillFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	return ratio, !p.opts.linearLight
+}
+
+func (p *resizeToFillFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	if sx == 1 && sy == 1 {
+		return p
+	}
+	// the region of the source image that is resized and cropped to the result
+	b := p.Bounds(srcBounds)
+	tmpw, tmph := p.resizedSize(srcBounds)
+	pt := anchorPt(image.Rect(0, 0, tmpw, tmph), b.Dx(), b.Dy(), p.anchor)
+	scaleX := float32(srcBounds.Dx()) / float32(tmpw)
+	scaleY := float32(srcBounds.Dy()) / float32(tmph)
+	region := RectF{
+		MinX: float32(srcBounds.Min.X) + float32(pt.X)*scaleX,
+		MinY: float32(srcBounds.Min.Y) + float32(pt.Y)*scaleY,
+		MaxX: float32(srcBounds.Min.X) + float32(pt.X+b.Dx())*scaleX,
+		MaxY: float32(srcBounds.Min.Y) + float32(pt.Y+b.Dy())*scaleY,
+	}
+	return resizePlane(region, b.Dx(), b.Dy(), sx, sy, p.resampling)
+}
+
+func (p *gausssianBlurFilter) yCbCrRatio(srcBounds image.Rectangle, ratio image.YCbCrSubsampleRatio) (image.YCbCrSubsampleRatio, bool) {
+	return ratio, yCbCrAligned(srcBounds.Min, ratio)
+}
+
+func (p *gausssianBlurFilter) planeFilter(srcBounds, dstPlane image.Rectangle, sx, sy int) Filter {
+	if sx == 1 && sy == 1 {
+		return p
+	}
+	return &planeBlurFilter{
+		sigmaX: p.sigma / float32(sx),
+		sigmaY: p.sigma / float32(sy),
+	}
+}
+
+// planeBlurFilter is the gaussian blur with different horizontal and vertical sigmas
+// used for the subsampled chroma planes.
+type planeBlurFilter struct {
+	sigmaX, sigmaY float32
+}
+
+func (p *planeBlurFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	dstBounds = image.Rect(0, 0, srcBounds.Dx(), srcBounds.Dy())
+	return
+}
+
+func (p *planeBlurFilter) Draw(dst draw.Image, src image.Image, options *Options) {
+	if options == nil {
+		options = &defaultOptions
+	}
+
+	srcb := src.Bounds()
+	if srcb.Dx() <= 0 || srcb.Dy() <= 0 {
+		return
+	}
+
+	tmp := newTempImageLike(src, srcb)
+	convolve1dh(tmp, src, gaussianBlurKernel1d(p.sigmaX), options)
+	convolve1dv(dst, tmp, gaussianBlurKernel1d(p.sigmaY), options)
+}
@@ -0,0 +1,194 @@
+package gift
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func genYCbCrTestImage(r image.Rectangle, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	img := image.NewYCbCr(r, ratio)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Y[img.YOffset(x, y)] = uint8(40 + x*2 + y)
+			img.Cb[img.COffset(x, y)] = uint8(128 + (x-y)/4)
+			img.Cr[img.COffset(x, y)] = uint8(124 + y/4)
+		}
+	}
+	return img
+}
+
+func TestYCbCrPlanes(t *testing.T) {
+	ratios := []image.YCbCrSubsampleRatio{
+		image.YCbCrSubsampleRatio444,
+		image.YCbCrSubsampleRatio422,
+		image.YCbCrSubsampleRatio420,
+		image.YCbCrSubsampleRatio440,
+		image.YCbCrSubsampleRatio411,
+		image.YCbCrSubsampleRatio410,
+	}
+	for _, ratio := range ratios {
+		img := genYCbCrTestImage(image.Rect(-3, 1, 10, 8), ratio)
+		for _, sub := range []*image.YCbCr{img, img.SubImage(image.Rect(-1, 2, 7, 7)).(*image.YCbCr)} {
+			py, pcb, pcr := yCbCrPlanes(sub)
+			for y := sub.Rect.Min.Y; y < sub.Rect.Max.Y; y++ {
+				for x := sub.Rect.Min.X; x < sub.Rect.Max.X; x++ {
+					sx, sy := yCbCrSubsampleFactors(ratio)
+					if py.GrayAt(x, y).Y != sub.Y[sub.YOffset(x, y)] ||
+						pcb.GrayAt(x/sx, y/sy).Y != sub.Cb[sub.COffset(x, y)] ||
+						pcr.GrayAt(x/sx, y/sy).Y != sub.Cr[sub.COffset(x, y)] {
+						t.Errorf("yCbCrPlanes %v %v: wrong sample at %d, %d", ratio, sub.Rect, x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestApplyYCbCr(t *testing.T) {
+	testData := []struct {
+		desc    string
+		filter  Filter
+		ratio   image.YCbCrSubsampleRatio
+		dstSize image.Point
+		dstRate image.YCbCrSubsampleRatio
+		diff    int
+	}{
+		{"resize 420", Resize(16, 0, LanczosResampling), image.YCbCrSubsampleRatio420, image.Pt(16, 12), image.YCbCrSubsampleRatio420, 4},
+		{"resize to fit 422", ResizeToFit(20, 20, LinearResampling), image.YCbCrSubsampleRatio422, image.Pt(20, 15), image.YCbCrSubsampleRatio422, 4},
+		{"crop 420", Crop(image.Rect(4, 6, 30, 40)), image.YCbCrSubsampleRatio420, image.Pt(26, 34), image.YCbCrSubsampleRatio420, 4},
+		{"crop to size 444", CropToSize(10, 10, BottomRightAnchor), image.YCbCrSubsampleRatio444, image.Pt(10, 10), image.YCbCrSubsampleRatio444, 2},
+		{"flip 420", FlipHorizontal(), image.YCbCrSubsampleRatio420, image.Pt(64, 48), image.YCbCrSubsampleRatio420, 4},
+		{"rotate90 422", Rotate90(), image.YCbCrSubsampleRatio422, image.Pt(48, 64), image.YCbCrSubsampleRatio440, 4},
+		{"transpose 440", Transpose(), image.YCbCrSubsampleRatio440, image.Pt(48, 64), image.YCbCrSubsampleRatio422, 4},
+		{"blur 420", GaussianBlur(1.5), image.YCbCrSubsampleRatio420, image.Pt(64, 48), image.YCbCrSubsampleRatio420, 4},
+		{"blur 422", GaussianBlur(1), image.YCbCrSubsampleRatio422, image.Pt(64, 48), image.YCbCrSubsampleRatio422, 4},
+		{"rotate90 411 (rgb)", Rotate90(), image.YCbCrSubsampleRatio411, image.Pt(48, 64), image.YCbCrSubsampleRatio411, 6},
+		{"invert (rgb)", Invert(), image.YCbCrSubsampleRatio420, image.Pt(64, 48), image.YCbCrSubsampleRatio420, 4},
+	}
+
+	for _, d := range testData {
+		src := genYCbCrTestImage(image.Rect(0, 0, 64, 48), d.ratio)
+		g := New(d.filter)
+		got := g.ApplyYCbCr(src)
+		if got.Rect.Size() != d.dstSize || got.SubsampleRatio != d.dstRate {
+			t.Errorf("test [%s] failed: expected %v %v got %v %v", d.desc, d.dstSize, d.dstRate, got.Rect.Size(), got.SubsampleRatio)
+			continue
+		}
+
+		want := image.NewNRGBA(g.Bounds(src.Bounds()))
+		g.Draw(want, src)
+	loop:
+		for y := 0; y < d.dstSize.Y; y++ {
+			for x := 0; x < d.dstSize.X; x++ {
+				c1 := want.NRGBAAt(x, y)
+				c2 := color.NRGBAModel.Convert(got.At(x, y)).(color.NRGBA)
+				if !compareColorsNRGBA(c1, c2, d.diff) {
+					t.Errorf("test [%s] failed: pixel %d, %d: expected %v got %v", d.desc, x, y, c1, c2)
+					break loop
+				}
+			}
+		}
+	}
+
+	// lossless plane transforms
+	src := genYCbCrTestImage(image.Rect(0, 0, 64, 48), image.YCbCrSubsampleRatio420)
+	got := New(FlipVertical(), FlipVertical()).ApplyYCbCr(src)
+	if !comparePix(src.Y, got.Y) || !comparePix(src.Cb, got.Cb) || !comparePix(src.Cr, got.Cr) {
+		t.Error("double flip of YCbCr planes is not lossless")
+	}
+	got = New().ApplyYCbCr(src)
+	if got == src || !comparePix(src.Y, got.Y) || !comparePix(src.Cb, got.Cb) || !comparePix(src.Cr, got.Cr) {
+		t.Error("ApplyYCbCr without filters must return a copy")
+	}
+}
+
+func TestApplyYCbCrChromaAlignment(t *testing.T) {
+	// sharp chroma stripes within the RGB gamut, so that misplaced chroma samples are visible
+	genImage := func(r image.Rectangle, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+		img := image.NewYCbCr(r, ratio)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Y[img.YOffset(x, y)] = uint8(100 + (x+y)/2)
+				img.Cb[img.COffset(x, y)] = uint8(90 + 76*((x/4)&1))
+				img.Cr[img.COffset(x, y)] = uint8(90 + 76*((y/4)&1))
+			}
+		}
+		return img
+	}
+
+	// The chroma planes are resampled directly at the chroma resolution, while the RGB path resamples
+	// at the luma resolution and then averages the chroma blocks. The two low-pass filters respond
+	// differently to the sharp stripes, so the resize filters get a larger tolerance, which is still
+	// well below the error of a misplaced chroma sample.
+	filters := []struct {
+		desc   string
+		filter Filter
+		diff   int
+	}{
+		{"resize 9x7", Resize(9, 7, LinearResampling), 6},
+		{"resize 21x17", Resize(21, 17, CubicResampling), 16},
+		{"resize 13x0", Resize(13, 0, LanczosResampling), 6},
+		{"resize to fit 9x9", ResizeToFit(9, 9, LinearResampling), 6},
+		{"resize to fill 9x9", ResizeToFill(9, 9, LinearResampling, CenterAnchor), 10},
+		{"resize to fill 21x11", ResizeToFill(21, 11, CubicResampling, BottomRightAnchor), 16},
+		{"crop odd offset", Crop(image.Rect(3, 5, 40, 30)), 3},
+		{"crop even offset", Crop(image.Rect(4, 6, 41, 31)), 3},
+		{"crop to size", CropToSize(21, 17, CenterAnchor), 3},
+		{"crop to size odd", CropToSize(20, 16, BottomRightAnchor), 3},
+		{"flip", FlipHorizontal(), 3},
+		{"rotate90", Rotate90(), 3},
+	}
+
+	ratios := []image.YCbCrSubsampleRatio{image.YCbCrSubsampleRatio420, image.YCbCrSubsampleRatio422}
+	rects := []image.Rectangle{image.Rect(0, 0, 64, 48), image.Rect(0, 0, 63, 47), image.Rect(1, 1, 63, 47)}
+
+	for _, ratio := range ratios {
+		for _, r := range rects {
+			src := genImage(r, ratio)
+			for _, f := range filters {
+				g := New(f.filter)
+				got := g.ApplyYCbCr(src)
+
+				// the RGB result subsampled in the same way
+				tmp := image.NewNRGBA(g.Bounds(src.Bounds()))
+				g.Draw(tmp, src)
+				want := convertToYCbCr(tmp, got.SubsampleRatio, &defaultOptions)
+
+				gy, gcb, gcr := yCbCrPlanes(got)
+				wy, wcb, wcr := yCbCrPlanes(want)
+				planes := [][2]*image.Gray{{gy, wy}, {gcb, wcb}, {gcr, wcr}}
+			loop:
+				for i, p := range planes {
+					if p[0].Rect != p[1].Rect {
+						t.Errorf("%v %v [%s]: plane %d: expected bounds %v got %v", ratio, r, f.desc, i, p[1].Rect, p[0].Rect)
+						break
+					}
+					for j := range p[1].Pix {
+						if d := int(p[0].Pix[j]) - int(p[1].Pix[j]); d < -f.diff || d > f.diff {
+							t.Errorf("%v %v [%s]: plane %d, sample %d: expected %d got %d", ratio, r, f.desc, i, j, p[1].Pix[j], p[0].Pix[j])
+							break loop
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// The crops at odd offsets are processed in RGB, the aligned crops and ResizeToFill use the planes.
+	testData := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Crop(image.Rect(3, 5, 40, 30)), false},
+		{Crop(image.Rect(4, 6, 41, 31)), true},
+		{ResizeToFill(9, 9, LinearResampling, CenterAnchor), true},
+		{FlipHorizontal(), false},
+	}
+	for _, d := range testData {
+		_, ok := d.filter.(yCbCrFilter).yCbCrRatio(image.Rect(0, 0, 63, 47), image.YCbCrSubsampleRatio420)
+		if ok != d.want {
+			t.Errorf("yCbCrRatio of %#v: expected %v got %v", d.filter, d.want, ok)
+		}
+	}
+}